@@ -0,0 +1,235 @@
+package httpx
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bindSources are the struct tags understood by Bind, in the order in which they are considered.
+var bindSources = []string{"path", "query", "header", "cookie"}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// FieldError describes a problem with a single field of a request.
+type FieldError struct {
+	// Field is the name of the field as it appears in the request.
+	Field string `json:"field"`
+	// Location is where the field was read from, such as "query" or "header".
+	Location string `json:"location,omitempty"`
+	// Rule is the validation rule that the field failed, if any.
+	Rule string `json:"rule,omitempty"`
+	// Message is a human readable description of the problem.
+	Message string `json:"message"`
+	// Err is the underlying error, if any.
+	Err error `json:"-"`
+}
+
+func (err FieldError) Error() string {
+	if err.Location == "" {
+		return fmt.Sprintf("%s: %s", err.Field, err.Message)
+	}
+	return fmt.Sprintf("%s %s: %s", err.Location, err.Field, err.Message)
+}
+
+func (err FieldError) Unwrap() error {
+	return err.Err
+}
+
+// BindError is returned by Bind when one or more fields could not be bound.
+// It is written as a BadRequest when returned from a Handler.
+type BindError struct {
+	Fields []FieldError
+}
+
+func (err *BindError) Error() string {
+	messages := make([]string, len(err.Fields))
+	for i, field := range err.Fields {
+		messages[i] = field.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (err *BindError) Unwrap() []error {
+	errs := make([]error, len(err.Fields))
+	for i, field := range err.Fields {
+		errs[i] = field
+	}
+	return errs
+}
+
+func (err *BindError) Write(writer ResponseWriter) error {
+	return BadRequest{err}.Write(writer)
+}
+
+// Bind populates the fields of the struct pointed to by target from their `path`, `query`, `header` and `cookie` tags.
+// Fields may be basic types, time.Duration, time.Time (RFC 3339 unless tagged with a `layout`),
+// encoding.TextUnmarshalers, or slices of and pointers to them; fields absent from the request are left untouched.
+// Every field is attempted; all failures are reported together in a *BindError.
+func Bind(request Request, target any) error {
+	if !isStructPointer(target) {
+		return fmt.Errorf("bind: target must be a non-nil pointer to a struct, got %T", target)
+	}
+	binder := binder{request: &request, query: request.URL.Query()}
+	binder.bindStruct(reflect.ValueOf(target).Elem())
+	if len(binder.errors) > 0 {
+		return &BindError{Fields: binder.errors}
+	}
+	return nil
+}
+
+// binder holds the state of a single Bind call.
+type binder struct {
+	request *Request
+	query   map[string][]string
+	errors  []FieldError
+}
+
+func (binder *binder) bindStruct(value reflect.Value) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			binder.bindStruct(value.Field(i))
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		for _, location := range bindSources {
+			name, ok := field.Tag.Lookup(location)
+			if !ok {
+				continue
+			}
+			values := binder.lookup(location, name)
+			if len(values) == 0 {
+				break
+			}
+			if err := setField(value.Field(i), values, field.Tag.Get("layout")); err != nil {
+				binder.errors = append(binder.errors, FieldError{
+					Field:    name,
+					Location: location,
+					Message:  err.Error(),
+					Err:      err,
+				})
+			}
+			break
+		}
+	}
+}
+
+// lookup returns the raw values of the named field at the given location.
+func (binder *binder) lookup(location string, name string) []string {
+	switch location {
+	case "path":
+		if value := binder.request.PathParam(name); value != "" {
+			return []string{value}
+		}
+	case "query":
+		return binder.query[name]
+	case "header":
+		return binder.request.Header.Values(name)
+	case "cookie":
+		if cookie, err := (*http.Request)(binder.request).Cookie(name); err == nil {
+			return []string{cookie.Value}
+		}
+	}
+	return nil
+}
+
+// setField parses values according to the type of field and stores the result in field.
+func setField(field reflect.Value, values []string, layout string) error {
+	if field.Kind() == reflect.Slice && !field.Type().Implements(textUnmarshalerType) {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, raw := range values {
+			if err := setValue(slice.Index(i), raw, layout); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	return setValue(field, values[0], layout)
+}
+
+// setValue parses raw according to the type of field and stores the result in field.
+func setValue(field reflect.Value, raw string, layout string) error {
+	if field.Kind() == reflect.Pointer {
+		element := reflect.New(field.Type().Elem())
+		if err := setValue(element.Elem(), raw, layout); err != nil {
+			return err
+		}
+		field.Set(element)
+		return nil
+	}
+	switch field.Type() {
+	case durationType:
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return unwrapParseError(err)
+		}
+		field.SetInt(int64(parsed))
+		return nil
+	case timeType:
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		parsed, err := time.Parse(layout, raw)
+		if err != nil {
+			return fmt.Errorf("expected time in %q format", layout)
+		}
+		field.Set(reflect.ValueOf(parsed))
+		return nil
+	}
+	if field.Addr().Type().Implements(textUnmarshalerType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return unwrapParseError(err)
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return unwrapParseError(err)
+		}
+		field.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return unwrapParseError(err)
+		}
+		field.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return unwrapParseError(err)
+		}
+		field.SetFloat(parsed)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// unwrapParseError strips the input echo from strconv errors so that messages do not repeat the raw value.
+func unwrapParseError(err error) error {
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		return numErr.Err
+	}
+	return err
+}
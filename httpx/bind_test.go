@@ -0,0 +1,158 @@
+package httpx
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type MockBindEmbedded struct {
+	Tenant string `header:"X-Tenant"`
+}
+
+type MockBindTarget struct {
+	MockBindEmbedded
+	Name     string        `query:"name"`
+	Count    int8          `query:"count"`
+	Size     uint          `query:"size"`
+	Ratio    float64       `query:"ratio"`
+	Enabled  bool          `query:"enabled"`
+	Timeout  time.Duration `query:"timeout"`
+	Since    time.Time     `query:"since"`
+	Day      time.Time     `query:"day" layout:"2006-01-02"`
+	Tags     []string      `query:"tag"`
+	Limit    *int          `query:"limit"`
+	Offset   *int          `query:"offset"`
+	ID       int           `path:"id"`
+	Owner    UUID          `path:"owner"`
+	Accept   []string      `header:"Accept"`
+	Session  string        `cookie:"session"`
+	Ignored  string
+	internal string `query:"internal"`
+}
+
+func CreateMockBindRequest(target string) *http.Request {
+	request := httptest.NewRequest("GET", target, nil)
+	request.SetPathValue("id", "9")
+	request.SetPathValue("owner", MOCK_UUID)
+	request.Header.Set("X-Tenant", "acme")
+	request.Header.Add("Accept", "text/plain")
+	request.Header.Add("Accept", "application/json")
+	request.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	return request
+}
+
+func TestBindPopulatesFieldsFromEveryLocation(t *testing.T) {
+	var target MockBindTarget
+	request := CreateMockBindRequest("/?name=x&count=-3&size=4&ratio=0.5&enabled=true&timeout=1m30s" +
+		"&since=2024-05-01T10:00:00Z&day=2024-05-02&tag=a&tag=b&limit=10&internal=y&Ignored=z")
+	if err := Bind(Request(*request), &target); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	limit := 10
+	owner, _ := ParseUUID(MOCK_UUID)
+	expected := MockBindTarget{
+		MockBindEmbedded: MockBindEmbedded{Tenant: "acme"},
+		Name:             "x",
+		Count:            -3,
+		Size:             4,
+		Ratio:            0.5,
+		Enabled:          true,
+		Timeout:          90 * time.Second,
+		Since:            time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Day:              time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC),
+		Tags:             []string{"a", "b"},
+		Limit:            &limit,
+		ID:               9,
+		Owner:            owner,
+		Accept:           []string{"text/plain", "application/json"},
+		Session:          "abc",
+	}
+	if !reflect.DeepEqual(target, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, target)
+	}
+}
+
+func TestBindLeavesMissingFieldsUntouched(t *testing.T) {
+	target := struct {
+		Name string `query:"name"`
+		ID   int    `path:"id"`
+		Auth string `cookie:"auth"`
+	}{Name: "default", ID: 1, Auth: "none"}
+	request := httptest.NewRequest("GET", "/", nil)
+	if err := Bind(Request(*request), &target); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if target.Name != "default" || target.ID != 1 || target.Auth != "none" {
+		t.Errorf("Expected defaults to be kept, got %+v", target)
+	}
+}
+
+func TestBindAggregatesFieldErrors(t *testing.T) {
+	var target MockBindTarget
+	request := CreateMockBindRequest("/?count=999&size=-1&ratio=x&enabled=maybe&timeout=soon&since=today&tag=a&offset=x")
+	request.SetPathValue("owner", "nobody")
+	err := Bind(Request(*request), &target)
+	var bindErr *BindError
+	if !errors.As(err, &bindErr) {
+		t.Fatalf("Expected *BindError, got %v", err)
+	}
+	if len(bindErr.Fields) != 8 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 8, len(bindErr.Fields))
+	}
+	if !errors.Is(err, strconv.ErrSyntax) || !errors.Is(err, ErrInvalidUUID) {
+		t.Errorf("Expected underlying errors to be reachable, got %v", err)
+	}
+	expected := "query count: value out of range"
+	if bindErr.Fields[0].Error() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, bindErr.Fields[0].Error())
+	}
+}
+
+func TestBindErrorListsEveryField(t *testing.T) {
+	err := &BindError{Fields: []FieldError{
+		{Field: "a", Location: "query", Message: "bad"},
+		{Field: "b", Message: "worse"},
+	}}
+	expected := "query a: bad; b: worse"
+	if err.Error() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, err.Error())
+	}
+}
+
+func TestBindErrorIsWrittenAsBadRequest(t *testing.T) {
+	writer := httptest.NewRecorder()
+	(&BindError{Fields: []FieldError{{Field: "a", Location: "query", Message: "bad"}}}).Write(writer)
+	if writer.Code != http.StatusBadRequest {
+		t.Errorf(EXPECTED_DIGIT_ERROR, http.StatusBadRequest, writer.Code)
+	}
+	expected := "bad request: query a: bad"
+	if writer.Body.String() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, writer.Body.String())
+	}
+}
+
+func TestBindReportsUnsupportedTypes(t *testing.T) {
+	var target struct {
+		Values map[string]string `query:"values"`
+	}
+	request := httptest.NewRequest("GET", "/?values=x", nil)
+	if err := Bind(Request(*request), &target); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestBindRejectsNonStructTargets(t *testing.T) {
+	var target []string
+	request := httptest.NewRequest("GET", "/", nil)
+	if err := Bind(Request(*request), &target); err == nil {
+		t.Error("Expected error, got nil")
+	}
+	if err := Bind(Request(*request), nil); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
@@ -0,0 +1,258 @@
+package httpx
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// OverflowPolicy decides what a Broker does with a subscriber that has fallen behind, whose buffer is full.
+type OverflowPolicy int
+
+const (
+	// CloseSlowSubscribers ends the subscription of a subscriber that has fallen behind.
+	// SSE clients reconnect on their own and catch up from the history of the topic.
+	CloseSlowSubscribers OverflowPolicy = iota
+	// DropEvents skips the events that do not fit in the buffer of a subscriber that has fallen behind.
+	DropEvents
+)
+
+const (
+	defaultBrokerBufferSize  = 16
+	defaultBrokerHistorySize = 64
+	defaultBrokerTopicExpiry = 5 * time.Minute
+)
+
+// Broker fans events published to named topics out to every subscriber of the topic, in process.
+// Publishing never blocks: subscribers whose buffer is full are handled according to the OverflowPolicy.
+// Each topic keeps a history of recent events for resuming subscribers until it expires after being idle.
+// A Broker is safe for concurrent use.
+type Broker struct {
+	mutex  sync.Mutex
+	topics map[string]*topic
+	// sequence is the number of the last event given an ID. It is shared by all topics, so that it outlives
+	// pruned topics and resuming clients do not receive an ID twice.
+	sequence    uint64
+	bufferSize  int
+	historySize int
+	topicExpiry time.Duration
+	overflow    OverflowPolicy
+	closed      bool
+}
+
+// topic holds the subscribers and recent events of a single topic.
+type topic struct {
+	history     []Event
+	subscribers map[*subscription]struct{}
+	// idleSince is the time of the last event or departure that left the topic without subscribers.
+	idleSince time.Time
+	expiry    *time.Timer
+}
+
+// subscription is a single subscriber of a topic.
+// Both channels are closed once the subscription ends.
+type subscription struct {
+	events chan Event
+	done   chan struct{}
+}
+
+// NewBroker creates a Broker that buffers 16 events per subscriber, keeps the last 64 events of each topic,
+// forgets topics idle for 5 minutes and closes subscribers that fall behind.
+func NewBroker() *Broker {
+	return &Broker{
+		topics:      map[string]*topic{},
+		bufferSize:  defaultBrokerBufferSize,
+		historySize: defaultBrokerHistorySize,
+		topicExpiry: defaultBrokerTopicExpiry,
+		overflow:    CloseSlowSubscribers,
+	}
+}
+
+// WithBufferSize sets the number of events buffered for each subscriber before it is considered to have fallen behind.
+func (broker *Broker) WithBufferSize(size int) *Broker {
+	broker.bufferSize = size
+	return broker
+}
+
+// WithHistory sets the number of recent events kept for each topic to replay to resuming subscribers.
+// A size of 0 disables resumption.
+func (broker *Broker) WithHistory(size int) *Broker {
+	broker.historySize = size
+	return broker
+}
+
+// WithTopicExpiry sets how long a topic without subscribers keeps its history after its last event.
+// A duration of 0 keeps the history of every topic until the broker is closed.
+func (broker *Broker) WithTopicExpiry(expiry time.Duration) *Broker {
+	broker.topicExpiry = expiry
+	return broker
+}
+
+// WithOverflowPolicy sets what happens to subscribers that fall behind.
+func (broker *Broker) WithOverflowPolicy(policy OverflowPolicy) *Broker {
+	broker.overflow = policy
+	return broker
+}
+
+// Publish sends event to every subscriber of the named topic and returns its ID.
+// Events without an ID are given the next number in the sequence of the broker, which all topics share.
+// Events published after the broker is closed are discarded.
+func (broker *Broker) Publish(name string, event Event) string {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+	if broker.closed {
+		return event.ID
+	}
+	topic := broker.topic(name)
+	broker.sequence++
+	if event.ID == "" {
+		event.ID = strconv.FormatUint(broker.sequence, 10)
+	}
+	if broker.historySize > 0 {
+		if len(topic.history) == broker.historySize {
+			copy(topic.history, topic.history[1:])
+			topic.history = topic.history[:len(topic.history)-1]
+		}
+		topic.history = append(topic.history, event)
+	}
+	for subscription := range topic.subscribers {
+		select {
+		case subscription.events <- event:
+		default:
+			if broker.overflow == CloseSlowSubscribers {
+				broker.remove(name, subscription)
+			}
+		}
+	}
+	broker.prune(name)
+	return event.ID
+}
+
+// Subscribe returns a channel that receives the events published to the named topic until ctx is done,
+// the subscriber falls behind and is closed, or the broker is closed. The channel is then closed.
+// If lastEventID is not empty, the events published after it, or the whole history if it is no longer known,
+// are replayed first.
+func (broker *Broker) Subscribe(ctx context.Context, name string, lastEventID string) <-chan Event {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+	if broker.closed {
+		events := make(chan Event)
+		close(events)
+		return events
+	}
+	topic := broker.topic(name)
+	var replay []Event
+	if lastEventID != "" {
+		replay = topic.history
+		for i, event := range topic.history {
+			if event.ID == lastEventID {
+				replay = topic.history[i+1:]
+			}
+		}
+	}
+	subscription := &subscription{
+		events: make(chan Event, broker.bufferSize+len(replay)),
+		done:   make(chan struct{}),
+	}
+	for _, event := range replay {
+		subscription.events <- event
+	}
+	topic.subscribers[subscription] = struct{}{}
+	go func() {
+		select {
+		case <-ctx.Done():
+			broker.mutex.Lock()
+			defer broker.mutex.Unlock()
+			broker.remove(name, subscription)
+			broker.prune(name)
+		case <-subscription.done:
+		}
+	}()
+	return subscription.events
+}
+
+// Subscribers returns the number of current subscribers of the named topic.
+func (broker *Broker) Subscribers(name string) int {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+	if topic, ok := broker.topics[name]; ok {
+		return len(topic.subscribers)
+	}
+	return 0
+}
+
+// Response subscribes the client of request to the named topic, resuming from its Last-Event-ID header,
+// and returns an SSEResponse that streams the events of the topic until the client disconnects.
+func (broker *Broker) Response(request Request, name string) SSEResponse {
+	return SSEResponse{Events: broker.Subscribe(request.Context(), name, request.LastEventID())}
+}
+
+// Close ends every subscription and discards events published afterwards.
+// It should be called before shutting a server down, so that open event streams end.
+func (broker *Broker) Close() {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+	broker.closed = true
+	for name, topic := range broker.topics {
+		if topic.expiry != nil {
+			topic.expiry.Stop()
+		}
+		for subscription := range topic.subscribers {
+			broker.remove(name, subscription)
+		}
+	}
+	broker.topics = map[string]*topic{}
+}
+
+// topic returns the named topic, creating it if needed. The mutex must be held.
+func (broker *Broker) topic(name string) *topic {
+	if existing, ok := broker.topics[name]; ok {
+		return existing
+	}
+	created := &topic{subscribers: map[*subscription]struct{}{}}
+	broker.topics[name] = created
+	return created
+}
+
+// remove ends a subscription if it is still part of the named topic. The mutex must be held.
+func (broker *Broker) remove(name string, subscription *subscription) {
+	topic, ok := broker.topics[name]
+	if !ok {
+		return
+	}
+	if _, ok := topic.subscribers[subscription]; !ok {
+		return
+	}
+	delete(topic.subscribers, subscription)
+	close(subscription.events)
+	close(subscription.done)
+}
+
+// prune forgets the named topic once it has no subscribers, right away if it has no history and otherwise
+// once it expires. The mutex must be held.
+func (broker *Broker) prune(name string) {
+	topic, ok := broker.topics[name]
+	if !ok || len(topic.subscribers) > 0 {
+		return
+	}
+	if len(topic.history) == 0 {
+		delete(broker.topics, name)
+		return
+	}
+	if broker.topicExpiry <= 0 {
+		return
+	}
+	topic.idleSince = time.Now()
+	if topic.expiry != nil {
+		topic.expiry.Reset(broker.topicExpiry)
+		return
+	}
+	topic.expiry = time.AfterFunc(broker.topicExpiry, func() {
+		broker.mutex.Lock()
+		defer broker.mutex.Unlock()
+		if broker.topics[name] == topic && len(topic.subscribers) == 0 && time.Since(topic.idleSince) >= broker.topicExpiry {
+			delete(broker.topics, name)
+		}
+	})
+}
@@ -0,0 +1,236 @@
+package httpx
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func receive(t *testing.T, events <-chan Event) (Event, bool) {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		return event, ok
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for an event")
+		return Event{}, false
+	}
+}
+
+func TestBrokerFansEventsOutToSubscribers(t *testing.T) {
+	broker := NewBroker()
+	first := broker.Subscribe(context.Background(), "status", "")
+	second := broker.Subscribe(context.Background(), "status", "")
+	other := broker.Subscribe(context.Background(), "other", "")
+	id := broker.Publish("status", Event{Data: "up"})
+	if id != "1" {
+		t.Errorf(EXPECTED_STRING_ERROR, "1", id)
+	}
+	for _, events := range []<-chan Event{first, second} {
+		if event, _ := receive(t, events); event.ID != "1" || event.Data != "up" {
+			t.Errorf("Expected event 1, got %+v", event)
+		}
+	}
+	if len(other) != 0 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 0, len(other))
+	}
+}
+
+func TestBrokerKeepsGivenEventIDs(t *testing.T) {
+	broker := NewBroker()
+	if id := broker.Publish("status", Event{ID: "custom"}); id != "custom" {
+		t.Errorf(EXPECTED_STRING_ERROR, "custom", id)
+	}
+	if id := broker.Publish("status", Event{}); id != "2" {
+		t.Errorf(EXPECTED_STRING_ERROR, "2", id)
+	}
+}
+
+func TestBrokerReplaysEventsAfterLastEventID(t *testing.T) {
+	broker := NewBroker()
+	for i := 0; i < 3; i++ {
+		broker.Publish("status", Event{})
+	}
+	events := broker.Subscribe(context.Background(), "status", "1")
+	for _, expected := range []string{"2", "3"} {
+		if event, _ := receive(t, events); event.ID != expected {
+			t.Errorf(EXPECTED_STRING_ERROR, expected, event.ID)
+		}
+	}
+	if len(events) != 0 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 0, len(events))
+	}
+}
+
+func TestBrokerReplaysWholeHistoryForUnknownLastEventID(t *testing.T) {
+	broker := NewBroker().WithHistory(2)
+	for i := 0; i < 3; i++ {
+		broker.Publish("status", Event{})
+	}
+	events := broker.Subscribe(context.Background(), "status", "1")
+	for _, expected := range []string{"2", "3"} {
+		if event, _ := receive(t, events); event.ID != expected {
+			t.Errorf(EXPECTED_STRING_ERROR, expected, event.ID)
+		}
+	}
+}
+
+func TestBrokerDoesNotReplayWithoutLastEventID(t *testing.T) {
+	broker := NewBroker()
+	broker.Publish("status", Event{})
+	if events := broker.Subscribe(context.Background(), "status", ""); len(events) != 0 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 0, len(events))
+	}
+}
+
+func TestBrokerKeepsSequenceOfPrunedTopics(t *testing.T) {
+	broker := NewBroker().WithHistory(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	events := broker.Subscribe(ctx, "status", "")
+	broker.Publish("status", Event{})
+	receive(t, events)
+	cancel()
+	for broker.Subscribers("status") != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if id := broker.Publish("status", Event{}); id != "2" {
+		t.Errorf(EXPECTED_STRING_ERROR, "2", id)
+	}
+}
+
+func TestBrokerForgetsIdleTopics(t *testing.T) {
+	broker := NewBroker().WithTopicExpiry(10 * time.Millisecond)
+	defer broker.Close()
+	broker.Publish("status", Event{})
+	ctx, cancel := context.WithCancel(context.Background())
+	broker.Subscribe(ctx, "orders", "")
+	broker.Publish("orders", Event{})
+	time.Sleep(50 * time.Millisecond)
+	if hasTopic(broker, "status") || !hasTopic(broker, "orders") {
+		t.Error("Expected only the subscribed topic to be kept")
+	}
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for hasTopic(broker, "orders") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if hasTopic(broker, "orders") {
+		t.Error("Expected the topic to be forgotten once its last subscriber left")
+	}
+	if id := broker.Publish("status", Event{}); id != "3" {
+		t.Errorf(EXPECTED_STRING_ERROR, "3", id)
+	}
+}
+
+func TestBrokerKeepsTopicsWithoutExpiry(t *testing.T) {
+	broker := NewBroker().WithTopicExpiry(0)
+	broker.Publish("status", Event{})
+	if !hasTopic(broker, "status") {
+		t.Error("Expected the topic to be kept")
+	}
+}
+
+func hasTopic(broker *Broker, name string) bool {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+	_, ok := broker.topics[name]
+	return ok
+}
+
+func TestBrokerNumbersEventsAcrossTopics(t *testing.T) {
+	broker := NewBroker()
+	broker.Publish("status", Event{})
+	if id := broker.Publish("orders", Event{}); id != "2" {
+		t.Errorf(EXPECTED_STRING_ERROR, "2", id)
+	}
+}
+
+func TestBrokerClosesSlowSubscribers(t *testing.T) {
+	broker := NewBroker().WithBufferSize(1)
+	events := broker.Subscribe(context.Background(), "status", "")
+	broker.Publish("status", Event{})
+	broker.Publish("status", Event{})
+	if event, ok := receive(t, events); !ok || event.ID != "1" {
+		t.Errorf("Expected buffered event 1, got %+v", event)
+	}
+	if _, ok := receive(t, events); ok {
+		t.Error("Expected subscription to be closed")
+	}
+	if broker.Subscribers("status") != 0 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 0, broker.Subscribers("status"))
+	}
+}
+
+func TestBrokerDropsEventsForSlowSubscribers(t *testing.T) {
+	broker := NewBroker().WithBufferSize(1).WithOverflowPolicy(DropEvents)
+	events := broker.Subscribe(context.Background(), "status", "")
+	broker.Publish("status", Event{})
+	broker.Publish("status", Event{})
+	broker.Publish("status", Event{})
+	if event, _ := receive(t, events); event.ID != "1" {
+		t.Errorf(EXPECTED_STRING_ERROR, "1", event.ID)
+	}
+	broker.Publish("status", Event{})
+	if event, _ := receive(t, events); event.ID != "4" {
+		t.Errorf(EXPECTED_STRING_ERROR, "4", event.ID)
+	}
+}
+
+func TestBrokerUnsubscribesWhenContextIsDone(t *testing.T) {
+	broker := NewBroker().WithHistory(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	events := broker.Subscribe(ctx, "status", "")
+	cancel()
+	if _, ok := receive(t, events); ok {
+		t.Error("Expected subscription to be closed")
+	}
+	if broker.Subscribers("status") != 0 || len(broker.topics) != 0 {
+		t.Errorf("Expected topic to be forgotten, got %d topics", len(broker.topics))
+	}
+}
+
+func TestBrokerCloseEndsSubscriptions(t *testing.T) {
+	broker := NewBroker()
+	events := broker.Subscribe(context.Background(), "status", "")
+	broker.Close()
+	if _, ok := receive(t, events); ok {
+		t.Error("Expected subscription to be closed")
+	}
+	if _, ok := receive(t, broker.Subscribe(context.Background(), "status", "")); ok {
+		t.Error("Expected subscription after close to be closed")
+	}
+	broker.Publish("status", Event{})
+}
+
+func TestBrokerResponseResumesFromLastEventID(t *testing.T) {
+	broker := NewBroker()
+	defer broker.Close()
+	broker.Publish("status", Event{Data: "first"})
+	broker.Publish("status", Event{Data: "second"})
+	router := NewRouter()
+	router.Route(GET, "/{topic}/", func(r Request) (Response, error) {
+		return broker.Response(r, r.PathParam("topic")), nil
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/status/", nil)
+	request.Header.Set(LastEventIDHeader, "1")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("Failed to make request, got %v", err)
+	}
+	defer response.Body.Close()
+	reader := bufio.NewReader(response.Body)
+	expected := []string{"id: 2\n", "data: second\n", "\n", "id: 3\n", "data: third\n"}
+	for i, line := range expected {
+		if i == 3 {
+			broker.Publish("status", Event{Data: "third"})
+		}
+		if actual, _ := reader.ReadString('\n'); actual != line {
+			t.Errorf(EXPECTED_STRING_ERROR, line, actual)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+)
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// CBOREncoder encodes values as RFC 8949 application/cbor, mapped as encoding/json would map them,
+// with map keys in the deterministic order of RFC 8949 section 4.2.1.
+// It is only available once added with Server.WithEncoder; fields tagged json:"-" are skipped,
+// but json.Marshaler implementations are not called.
+type CBOREncoder struct{}
+
+func (CBOREncoder) MediaType() string {
+	return "application/cbor"
+}
+
+func (CBOREncoder) Encode(writer io.Writer, value any) error {
+	encoder := cborEncoder{}
+	if err := encoder.encode(reflect.ValueOf(value)); err != nil {
+		return err
+	}
+	_, err := writer.Write(encoder.buffer)
+	return err
+}
+
+const (
+	cborUnsigned = 0
+	cborNegative = 1
+	cborBytes    = 2
+	cborText     = 3
+	cborArray    = 4
+	cborMap      = 5
+	cborTag      = 6
+	cborFalse    = 0xf4
+	cborTrue     = 0xf5
+	cborNull     = 0xf6
+	cborFloat64  = 0xfb
+)
+
+type cborEncoder struct {
+	buffer []byte
+}
+
+// head writes the initial byte of a data item, with its argument in the shortest form.
+func (encoder *cborEncoder) head(major byte, argument uint64) {
+	major <<= 5
+	switch {
+	case argument < 24:
+		encoder.buffer = append(encoder.buffer, major|byte(argument))
+	case argument <= math.MaxUint8:
+		encoder.buffer = append(encoder.buffer, major|24, byte(argument))
+	case argument <= math.MaxUint16:
+		encoder.buffer = binary.BigEndian.AppendUint16(append(encoder.buffer, major|25), uint16(argument))
+	case argument <= math.MaxUint32:
+		encoder.buffer = binary.BigEndian.AppendUint32(append(encoder.buffer, major|26), uint32(argument))
+	default:
+		encoder.buffer = binary.BigEndian.AppendUint64(append(encoder.buffer, major|27), argument)
+	}
+}
+
+func (encoder *cborEncoder) text(text string) {
+	encoder.head(cborText, uint64(len(text)))
+	encoder.buffer = append(encoder.buffer, text...)
+}
+
+func (encoder *cborEncoder) encode(value reflect.Value) error {
+	if !value.IsValid() {
+		encoder.buffer = append(encoder.buffer, cborNull)
+		return nil
+	}
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		if value.IsNil() {
+			encoder.buffer = append(encoder.buffer, cborNull)
+			return nil
+		}
+	}
+	if value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		return encoder.encode(value.Elem())
+	}
+	if value.Type() == timeType {
+		encoder.head(cborTag, 0)
+		encoder.text(value.Interface().(time.Time).Format(time.RFC3339Nano))
+		return nil
+	}
+	if value.Type().Implements(textMarshalerType) {
+		text, err := value.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+		encoder.text(string(text))
+		return nil
+	}
+	switch value.Kind() {
+	case reflect.Bool:
+		if value.Bool() {
+			encoder.buffer = append(encoder.buffer, cborTrue)
+		} else {
+			encoder.buffer = append(encoder.buffer, cborFalse)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n := value.Int(); n >= 0 {
+			encoder.head(cborUnsigned, uint64(n))
+		} else {
+			encoder.head(cborNegative, uint64(-1-n))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		encoder.head(cborUnsigned, value.Uint())
+	case reflect.Float32, reflect.Float64:
+		encoder.buffer = binary.BigEndian.AppendUint64(append(encoder.buffer, cborFloat64), math.Float64bits(value.Float()))
+	case reflect.String:
+		encoder.text(value.String())
+	case reflect.Slice, reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			encoder.head(cborBytes, uint64(value.Len()))
+			for i := 0; i < value.Len(); i++ {
+				encoder.buffer = append(encoder.buffer, byte(value.Index(i).Uint()))
+			}
+			return nil
+		}
+		encoder.head(cborArray, uint64(value.Len()))
+		for i := 0; i < value.Len(); i++ {
+			if err := encoder.encode(value.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		return encoder.encodeMap(value)
+	case reflect.Struct:
+		fields, values := nonEmptyFields(value)
+		encoder.head(cborMap, uint64(len(fields)))
+		for i, field := range fields {
+			encoder.text(field.name)
+			if err := encoder.encode(values[i]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cbor: unsupported type %s", value.Type())
+	}
+	return nil
+}
+
+// encodeMap writes a map with its entries sorted by the bytewise order of their encoded keys.
+func (encoder *cborEncoder) encodeMap(value reflect.Value) error {
+	type entry struct {
+		key   []byte
+		value reflect.Value
+	}
+	entries := make([]entry, 0, value.Len())
+	iterator := value.MapRange()
+	for iterator.Next() {
+		keyEncoder := cborEncoder{}
+		if err := keyEncoder.encode(iterator.Key()); err != nil {
+			return err
+		}
+		entries = append(entries, entry{keyEncoder.buffer, iterator.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].key, entries[j].key) < 0
+	})
+	encoder.head(cborMap, uint64(len(entries)))
+	for _, entry := range entries {
+		encoder.buffer = append(encoder.buffer, entry.key...)
+		if err := encoder.encode(entry.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+	"testing"
+	"time"
+)
+
+func encodeCBOR(t *testing.T, value any) string {
+	buffer := &bytes.Buffer{}
+	if err := (CBOREncoder{}).Encode(buffer, value); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return hex.EncodeToString(buffer.Bytes())
+}
+
+// Expected encodings are taken from RFC 8949 appendix A where available.
+func TestCBOREncoderEncodesScalars(t *testing.T) {
+	cases := []struct {
+		value    any
+		expected string
+	}{
+		{0, "00"},
+		{23, "17"},
+		{24, "1818"},
+		{100, "1864"},
+		{1000, "1903e8"},
+		{1000000, "1a000f4240"},
+		{uint64(1000000000000), "1b000000e8d4a51000"},
+		{uint64(math.MaxUint64), "1bffffffffffffffff"},
+		{-1, "20"},
+		{-100, "3863"},
+		{-1000, "3903e7"},
+		{1.1, "fb3ff199999999999a"},
+		{false, "f4"},
+		{true, "f5"},
+		{nil, "f6"},
+		{"", "60"},
+		{"IETF", "6449455446"},
+		{"ü", "62c3bc"},
+		{[]byte{1, 2, 3, 4}, "4401020304"},
+	}
+	for _, c := range cases {
+		if actual := encodeCBOR(t, c.value); actual != c.expected {
+			t.Errorf("Encoding %v: "+EXPECTED_STRING_ERROR, c.value, c.expected, actual)
+		}
+	}
+}
+
+func TestCBOREncoderEncodesCollections(t *testing.T) {
+	cases := []struct {
+		value    any
+		expected string
+	}{
+		{[]int{}, "80"},
+		{[]any{1, []int{2, 3}, [2]int{4, 5}}, "8301820203820405"},
+		{map[string]any{}, "a0"},
+		{map[int]int{3: 4, 1: 2}, "a201020304"},
+		{map[string]any{"a": 1, "b": []int{2, 3}}, "a26161016162820203"},
+		{[]string(nil), "f6"},
+		{map[string]int(nil), "f6"},
+	}
+	for _, c := range cases {
+		if actual := encodeCBOR(t, c.value); actual != c.expected {
+			t.Errorf("Encoding %v: "+EXPECTED_STRING_ERROR, c.value, c.expected, actual)
+		}
+	}
+}
+
+func TestCBOREncoderEncodesStructsByJSONName(t *testing.T) {
+	type embedded struct {
+		C int `json:"c"`
+	}
+	value := struct {
+		embedded
+		A       int    `json:"a"`
+		B       string `json:"b,omitempty"`
+		Skipped int    `json:"-"`
+		private int
+	}{embedded{3}, 1, "", 2, 4}
+	if actual := encodeCBOR(t, &value); actual != "a2616303616101" {
+		t.Errorf(EXPECTED_STRING_ERROR, "a2616303616101", actual)
+	}
+}
+
+func TestCBOREncoderEncodesTimesAndTextMarshalers(t *testing.T) {
+	moment := time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC)
+	if actual := encodeCBOR(t, moment); actual != "c074323031332d30332d32315432303a30343a30305a" {
+		t.Errorf(EXPECTED_STRING_ERROR, "c074323031332d30332d32315432303a30343a30305a", actual)
+	}
+	uuid, _ := ParseUUID(MOCK_UUID)
+	expected := "7824" + hex.EncodeToString([]byte(MOCK_UUID))
+	if actual := encodeCBOR(t, uuid); actual != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, actual)
+	}
+}
+
+func TestCBOREncoderRejectsUnsupportedTypes(t *testing.T) {
+	if err := (CBOREncoder{}).Encode(&bytes.Buffer{}, []any{make(chan int)}); err == nil {
+		t.Error("Expected error, got nil")
+	}
+	if err := (CBOREncoder{}).Encode(&bytes.Buffer{}, map[string]any{"a": func() {}}); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
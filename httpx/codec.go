@@ -0,0 +1,424 @@
+package httpx
+
+import (
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Encoder serializes the bodies of ObjectResponses for a single media type.
+// Encoders may also implement CanEncode(value any) bool to be skipped for values they do not support.
+type Encoder interface {
+	// MediaType returns the Content-Type of the encoded values, such as "application/json".
+	MediaType() string
+	// Encode writes the encoded value to writer.
+	Encode(writer io.Writer, value any) error
+}
+
+// selectiveEncoder is implemented by encoders that only support some values.
+type selectiveEncoder interface {
+	CanEncode(value any) bool
+}
+
+// defaultEncoders are the encoders available to content negotiation unless others are added with
+// Server.WithEncoder. Only JSON is available by default, so that the json tags and json.Marshaler
+// implementations of response bodies decide what is written.
+var defaultEncoders = []Encoder{JSONEncoder{}}
+
+// rankEncoders returns the acceptable encoders for value, ordered by the quality of the most specific media range
+// of the Accept header values that matches them, with ties going to the encoder listed first.
+// Without an Accept header, the encoders that support value are returned in order.
+func rankEncoders(accept []string, encoders []Encoder, value any) []Encoder {
+	type rankedEncoder struct {
+		encoder Encoder
+		quality float64
+	}
+	ranges := parseAccept(accept)
+	var candidates []rankedEncoder
+	for _, encoder := range encoders {
+		if selective, ok := encoder.(selectiveEncoder); ok && !selective.CanEncode(value) {
+			continue
+		}
+		quality := 1.0
+		if len(ranges) > 0 {
+			quality = qualityOf(ranges, encoder.MediaType())
+		}
+		if quality > 0 {
+			candidates = append(candidates, rankedEncoder{encoder, quality})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].quality > candidates[j].quality })
+	ranked := make([]Encoder, len(candidates))
+	for i, candidate := range candidates {
+		ranked[i] = candidate.encoder
+	}
+	return ranked
+}
+
+// availableMediaTypes lists the media types of the encoders that support value.
+func availableMediaTypes(encoders []Encoder, value any) []string {
+	var available []string
+	for _, encoder := range encoders {
+		if selective, ok := encoder.(selectiveEncoder); ok && !selective.CanEncode(value) {
+			continue
+		}
+		mediaType, _, _ := mime.ParseMediaType(encoder.MediaType())
+		available = append(available, mediaType)
+	}
+	return available
+}
+
+// mediaRange is a single entry of an Accept header.
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+func parseAccept(accept []string) []mediaRange {
+	var ranges []mediaRange
+	for _, header := range accept {
+		for _, entry := range strings.Split(header, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(entry))
+			if err != nil {
+				continue
+			}
+			quality := 1.0
+			if q, ok := params["q"]; ok {
+				if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+					quality = parsed
+				}
+			}
+			ranges = append(ranges, mediaRange{mediaType, quality})
+		}
+	}
+	return ranges
+}
+
+// qualityOf returns the quality of the most specific range matching contentType, or 0 if none match.
+func qualityOf(ranges []mediaRange, contentType string) float64 {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	quality, specificity := 0.0, -1
+	for _, mediaRange := range ranges {
+		var rangeSpecificity int
+		switch mediaRange.mediaType {
+		case mediaType:
+			rangeSpecificity = 2
+		case mainType + "/*":
+			rangeSpecificity = 1
+		case "*/*":
+			rangeSpecificity = 0
+		default:
+			continue
+		}
+		if rangeSpecificity > specificity {
+			quality, specificity = mediaRange.quality, rangeSpecificity
+		}
+	}
+	return quality
+}
+
+// sameMediaType reports whether two content types have the same media type, ignoring parameters.
+func sameMediaType(first string, second string) bool {
+	firstType, _, _ := mime.ParseMediaType(first)
+	secondType, _, _ := mime.ParseMediaType(second)
+	return firstType == secondType
+}
+
+// JSONEncoder encodes values as application/json using encoding/json.
+type JSONEncoder struct{}
+
+func (JSONEncoder) MediaType() string {
+	return "application/json"
+}
+
+func (JSONEncoder) Encode(writer io.Writer, value any) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(encoded)
+	return err
+}
+
+// XMLEncoder encodes values as application/xml using encoding/xml, once added with Server.WithEncoder.
+// encoding/xml ignores json tags and json.Marshaler, so fields excluded from JSON are written unless tagged xml:"-".
+// Values that do not marshal to a single named element, such as maps and slices, are not supported.
+type XMLEncoder struct{}
+
+func (XMLEncoder) MediaType() string {
+	return "application/xml"
+}
+
+var xmlMarshalerType = reflect.TypeOf((*xml.Marshaler)(nil)).Elem()
+
+func (XMLEncoder) CanEncode(value any) bool {
+	valueType := reflect.TypeOf(value)
+	for valueType != nil && valueType.Kind() == reflect.Pointer {
+		valueType = valueType.Elem()
+	}
+	switch {
+	case valueType == nil:
+		return false
+	case valueType.Implements(xmlMarshalerType) || reflect.PointerTo(valueType).Implements(xmlMarshalerType):
+		return true
+	case valueType.Kind() == reflect.Slice || valueType.Kind() == reflect.Array:
+		return false
+	case valueType.Kind() == reflect.Struct && valueType.Name() == "":
+		if field, ok := valueType.FieldByName("XMLName"); !ok || field.Tag.Get("xml") == "" {
+			return false
+		}
+	}
+	return xmlMarshalable(valueType, map[reflect.Type]bool{})
+}
+
+var xmlMarshalableCache sync.Map
+
+// xmlMarshalable reports whether encoding/xml can marshal values of valueType.
+// Interface values are assumed to be marshalable, since their type is only known when encoding.
+func xmlMarshalable(valueType reflect.Type, visiting map[reflect.Type]bool) bool {
+	if cached, ok := xmlMarshalableCache.Load(valueType); ok {
+		return cached.(bool)
+	}
+	if visiting[valueType] {
+		return true
+	}
+	visiting[valueType] = true
+	marshalable := true
+	switch {
+	case valueType.Implements(xmlMarshalerType) || valueType.Implements(textMarshalerType):
+	case valueType.Kind() == reflect.Pointer || valueType.Kind() == reflect.Slice || valueType.Kind() == reflect.Array:
+		marshalable = xmlMarshalable(valueType.Elem(), visiting)
+	case valueType.Kind() == reflect.Struct:
+		for i := 0; i < valueType.NumField() && marshalable; i++ {
+			field := valueType.Field(i)
+			if (field.IsExported() || field.Anonymous) && field.Tag.Get("xml") != "-" {
+				marshalable = xmlMarshalable(field.Type, visiting)
+			}
+		}
+	case valueType.Kind() == reflect.Map, valueType.Kind() == reflect.Chan, valueType.Kind() == reflect.Func,
+		valueType.Kind() == reflect.Complex64, valueType.Kind() == reflect.Complex128, valueType.Kind() == reflect.UnsafePointer:
+		marshalable = false
+	}
+	delete(visiting, valueType)
+	if len(visiting) == 0 {
+		xmlMarshalableCache.Store(valueType, marshalable)
+	}
+	return marshalable
+}
+
+func (XMLEncoder) Encode(writer io.Writer, value any) error {
+	encoded, err := xml.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(encoded)
+	return err
+}
+
+// TextEncoder encodes strings, byte slices, numbers, booleans, errors and fmt.Stringers as text/plain.
+// It is not available to content negotiation unless added with Server.WithEncoder.
+type TextEncoder struct{}
+
+func (TextEncoder) MediaType() string {
+	return "text/plain; charset=utf-8"
+}
+
+func (TextEncoder) CanEncode(value any) bool {
+	switch value.(type) {
+	case []byte, fmt.Stringer, error:
+		return true
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func (TextEncoder) Encode(writer io.Writer, value any) error {
+	if bytes, ok := value.([]byte); ok {
+		_, err := writer.Write(bytes)
+		return err
+	}
+	_, err := fmt.Fprint(writer, value)
+	return err
+}
+
+// CSVEncoder encodes slices as text/csv. It is not available to content negotiation unless added with
+// Server.WithEncoder.
+// Slices of structs are written with a header row of field names, followed by a row per element.
+// Slices of slices are written with a row per element and no header.
+type CSVEncoder struct{}
+
+func (CSVEncoder) MediaType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (CSVEncoder) CanEncode(value any) bool {
+	valueType := reflect.TypeOf(value)
+	if valueType == nil || (valueType.Kind() != reflect.Slice && valueType.Kind() != reflect.Array) {
+		return false
+	}
+	elementType := valueType.Elem()
+	if elementType.Kind() == reflect.Pointer {
+		elementType = elementType.Elem()
+	}
+	return elementType.Kind() == reflect.Struct || elementType.Kind() == reflect.Slice || elementType.Kind() == reflect.Array
+}
+
+func (encoder CSVEncoder) Encode(writer io.Writer, value any) error {
+	if !encoder.CanEncode(value) {
+		return fmt.Errorf("csv: unsupported type %T", value)
+	}
+	rows := reflect.ValueOf(value)
+	csvWriter := csv.NewWriter(writer)
+	elementType := rows.Type().Elem()
+	if elementType.Kind() == reflect.Pointer {
+		elementType = elementType.Elem()
+	}
+	if elementType.Kind() == reflect.Struct {
+		fields := encodedFields(elementType)
+		header := make([]string, len(fields))
+		for i, field := range fields {
+			header[i] = field.name
+		}
+		csvWriter.Write(header)
+		for i := 0; i < rows.Len(); i++ {
+			row := indirect(rows.Index(i))
+			record := make([]string, len(fields))
+			if row.IsValid() {
+				for j, field := range fields {
+					record[j] = formatCell(row.FieldByIndex(field.index))
+				}
+			}
+			csvWriter.Write(record)
+		}
+	} else {
+		for i := 0; i < rows.Len(); i++ {
+			row := rows.Index(i)
+			record := make([]string, row.Len())
+			for j := range record {
+				record[j] = formatCell(row.Index(j))
+			}
+			csvWriter.Write(record)
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// formatCell formats a single CSV value. Nil values are written as empty cells.
+func formatCell(value reflect.Value) string {
+	value = indirect(value)
+	if !value.IsValid() {
+		return ""
+	}
+	if marshaler, ok := value.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		if err == nil {
+			return string(text)
+		}
+	}
+	return fmt.Sprint(value.Interface())
+}
+
+// indirect follows pointers and interfaces, returning the zero Value if it encounters a nil.
+func indirect(value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+// encodedField is a struct field as it appears in encoded output.
+type encodedField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+var encodedFieldCache sync.Map
+
+// encodedFields returns the exported fields of a struct type under their JSON names, honouring the
+// "-" and omitempty options of the json tag. Fields of embedded structs without a JSON name are promoted.
+func encodedFields(structType reflect.Type) []encodedField {
+	if cached, ok := encodedFieldCache.Load(structType); ok {
+		return cached.([]encodedField)
+	}
+	var fields []encodedField
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for _, promoted := range encodedFields(field.Type) {
+				promoted.index = append([]int{i}, promoted.index...)
+				fields = append(fields, promoted)
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, encodedField{
+			name:      name,
+			index:     []int{i},
+			omitEmpty: strings.Contains(","+options+",", ",omitempty,"),
+		})
+	}
+	encodedFieldCache.Store(structType, fields)
+	return fields
+}
+
+// isEmptyValue reports whether value is empty in the sense of the omitempty option of encoding/json.
+func isEmptyValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return value.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return value.IsZero()
+	}
+	return false
+}
+
+// nonEmptyFields returns the fields of a struct value that are written, skipping empty omitempty fields.
+func nonEmptyFields(value reflect.Value) ([]encodedField, []reflect.Value) {
+	fields := encodedFields(value.Type())
+	written := make([]encodedField, 0, len(fields))
+	values := make([]reflect.Value, 0, len(fields))
+	for _, field := range fields {
+		fieldValue := value.FieldByIndex(field.index)
+		if field.omitEmpty && isEmptyValue(fieldValue) {
+			continue
+		}
+		written = append(written, field)
+		values = append(values, fieldValue)
+	}
+	return written, values
+}
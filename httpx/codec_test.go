@@ -0,0 +1,370 @@
+package httpx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type MockCodecItem struct {
+	Name  string `json:"name" xml:"name"`
+	Count int    `json:"count" xml:"count"`
+}
+
+type MockSecretItem struct {
+	Name   string `json:"name"`
+	Secret string `json:"-"`
+}
+
+type MockMarshalerItem struct {
+	Secret string
+}
+
+func (MockMarshalerItem) MarshalJSON() ([]byte, error) { return []byte(`{"secret":"redacted"}`), nil }
+
+type MockFailingTextMarshaler struct{}
+
+func (MockFailingTextMarshaler) MarshalText() ([]byte, error) {
+	return nil, fmt.Errorf("failing marshaler")
+}
+
+type MockYAMLEncoder struct{}
+
+func (MockYAMLEncoder) MediaType() string { return "application/yaml" }
+
+func (MockYAMLEncoder) Encode(writer io.Writer, value any) error {
+	_, err := fmt.Fprintf(writer, "value: %v", value)
+	return err
+}
+
+type MockReplacementJSONEncoder struct{}
+
+func (MockReplacementJSONEncoder) MediaType() string { return "application/json; charset=utf-8" }
+
+func (MockReplacementJSONEncoder) Encode(writer io.Writer, value any) error {
+	_, err := writer.Write([]byte("replaced"))
+	return err
+}
+
+type MockFailingEncoder struct{}
+
+func (MockFailingEncoder) MediaType() string { return "application/x-failing" }
+
+func (MockFailingEncoder) Encode(writer io.Writer, value any) error {
+	writer.Write([]byte("partial"))
+	return fmt.Errorf("failing encoder")
+}
+
+// allEncoders are the encoders of this package, in the order they are added by serverWithAllEncoders.
+var allEncoders = []Encoder{
+	JSONEncoder{},
+	XMLEncoder{},
+	CBOREncoder{},
+	MessagePackEncoder{},
+	TextEncoder{},
+	CSVEncoder{},
+}
+
+func serverWithAllEncoders() *Server {
+	server := NewServer("")
+	for _, encoder := range allEncoders[1:] {
+		server = server.WithEncoder(encoder)
+	}
+	return server
+}
+
+func serveObject(server *Server, body any, accept ...string) *httptest.ResponseRecorder {
+	handler := adapt(func(Request) (Response, error) {
+		return ObjectResponse{StatusCode: 200, Body: body}, nil
+	})
+	writer := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, MOCK_PATH, nil)
+	for _, value := range accept {
+		request.Header.Add("Accept", value)
+	}
+	handler.ServeHTTP(writer, request.WithContext(server.server.BaseContext(nil)))
+	return writer
+}
+
+func TestRankEncodersPrefersFirstEncoderWithoutAcceptHeader(t *testing.T) {
+	ranked := rankEncoders(nil, defaultEncoders, MockCodecItem{})
+	if len(ranked) == 0 || ranked[0] != (JSONEncoder{}) {
+		t.Errorf("Expected JSONEncoder, got %v", ranked)
+	}
+}
+
+func TestRankEncodersHonoursQualityValues(t *testing.T) {
+	ranked := rankEncoders([]string{"application/json;q=0.5, application/xml"}, allEncoders, MockCodecItem{})
+	if len(ranked) != 2 || ranked[0] != (XMLEncoder{}) || ranked[1] != (JSONEncoder{}) {
+		t.Errorf("Expected XMLEncoder then JSONEncoder, got %v", ranked)
+	}
+}
+
+func TestObjectResponseChoosesHighestQualityAmongManyEncoders(t *testing.T) {
+	cases := []struct {
+		accept      string
+		contentType string
+	}{
+		{"application/json;q=0.5, application/cbor;q=0.1, application/msgpack;q=0.9", "application/msgpack"},
+		{"application/json;q=0.5, application/msgpack;q=0.1, text/plain;q=0.3, application/cbor;q=0.9", "application/cbor"},
+		{"application/json;q=0.2, application/msgpack;q=0.5, application/cbor;q=0.4, text/plain;q=0.6", "text/plain; charset=utf-8"},
+		{"application/json;q=0.2, application/xml;q=0.3, application/msgpack;q=0.8, application/cbor;q=0.7", "application/msgpack"},
+	}
+	for _, c := range cases {
+		writer := serveObject(serverWithAllEncoders(), 1, c.accept)
+		if writer.Header().Get(CONTENT_TYPE_HEADER_KEY) != c.contentType {
+			t.Errorf(EXPECTED_STRING_ERROR, c.contentType, writer.Header().Get(CONTENT_TYPE_HEADER_KEY))
+		}
+	}
+}
+
+func TestRankEncodersPrefersMostSpecificRange(t *testing.T) {
+	ranked := rankEncoders([]string{"text/*;q=0.9", "text/plain;q=0", "*/*;q=0.1"}, allEncoders, "hello")
+	if len(ranked) == 0 || ranked[0] != (JSONEncoder{}) {
+		t.Errorf("Expected text/plain to be excluded by q=0 and JSON to match */*, got %v", ranked)
+	}
+}
+
+func TestRankEncodersSkipsEncodersThatCannotEncodeValue(t *testing.T) {
+	if ranked := rankEncoders([]string{"text/csv"}, allEncoders, MockCodecItem{}); len(ranked) != 0 {
+		t.Error("Expected CSV not to encode a single struct")
+	}
+	if ranked := rankEncoders([]string{"text/csv"}, allEncoders, []MockCodecItem{}); len(ranked) != 1 {
+		t.Error("Expected CSV to encode a slice of structs")
+	}
+}
+
+func TestRankEncodersIgnoresMalformedRanges(t *testing.T) {
+	ranked := rankEncoders([]string{"not a media type, application/cbor;q=abc"}, allEncoders, 1)
+	if len(ranked) == 0 || ranked[0] != (CBOREncoder{}) {
+		t.Errorf("Expected CBOREncoder, got %v", ranked)
+	}
+}
+
+func TestObjectResponseNegotiatesEncoding(t *testing.T) {
+	cases := []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"application/json", "application/json", `{"name":"a","count":1}`},
+		{"application/xml", "application/xml", "<MockCodecItem><name>a</name><count>1</count></MockCodecItem>"},
+		{"application/cbor", "application/cbor", "\xa2dnameaaecount\x01"},
+		{"application/msgpack", "application/msgpack", "\x82\xa4name\xa1a\xa5count\x01"},
+	}
+	for _, c := range cases {
+		writer := serveObject(serverWithAllEncoders(), MockCodecItem{"a", 1}, c.accept)
+		if writer.Header().Get(CONTENT_TYPE_HEADER_KEY) != c.contentType {
+			t.Errorf(EXPECTED_STRING_ERROR, c.contentType, writer.Header().Get(CONTENT_TYPE_HEADER_KEY))
+		}
+		if writer.Body.String() != c.body {
+			t.Errorf(EXPECTED_STRING_ERROR, c.body, writer.Body.String())
+		}
+		if writer.Header().Get("Vary") != "Accept" {
+			t.Errorf(EXPECTED_STRING_ERROR, "Accept", writer.Header().Get("Vary"))
+		}
+	}
+}
+
+func TestObjectResponsesKeepTheirOwnVaryValues(t *testing.T) {
+	handler := adapt(func(Request) (Response, error) {
+		return ObjectResponse{StatusCode: 200, Headers: http.Header{"Vary": {"Origin, Accept", "Cookie"}}, Body: MockCodecItem{"a", 1}}, nil
+	})
+	writer := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, MOCK_PATH, nil)
+	request.Header.Set("Accept", "application/xml")
+	handler.ServeHTTP(writer, request.WithContext(serverWithAllEncoders().server.BaseContext(nil)))
+	expected := []string{"Accept", "Origin", "Cookie"}
+	if !reflect.DeepEqual(writer.Header().Values("Vary"), expected) {
+		t.Errorf("Expected Vary %v, got %v", expected, writer.Header().Values("Vary"))
+	}
+}
+
+func TestObjectResponsePrefersJSONForBrowsersWhenXMLCannotEncode(t *testing.T) {
+	browser := "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	bodies := []any{
+		struct{ Name string }{"a"},
+		[]MockCodecItem{{"a", 1}},
+		struct{ Labels map[string]string }{map[string]string{"a": "b"}},
+	}
+	for _, body := range bodies {
+		writer := serveObject(serverWithAllEncoders(), body, browser)
+		if writer.Code != 200 || writer.Header().Get(CONTENT_TYPE_HEADER_KEY) != "application/json" {
+			t.Errorf("Expected 200 application/json for %T, got %d %q", body, writer.Code, writer.Header().Get(CONTENT_TYPE_HEADER_KEY))
+		}
+	}
+	writer := serveObject(serverWithAllEncoders(), MockCodecItem{"a", 1}, "text/html, */*;q=0.8")
+	if writer.Header().Get(CONTENT_TYPE_HEADER_KEY) != "application/json" {
+		t.Errorf(EXPECTED_STRING_ERROR, "application/json", writer.Header().Get(CONTENT_TYPE_HEADER_KEY))
+	}
+}
+
+func TestObjectResponseFallsBackWhenEncodingFails(t *testing.T) {
+	writer := serveObject(NewServer("").WithEncoder(MockFailingEncoder{}), MockCodecItem{"a", 1}, "application/x-failing, application/json;q=0.5")
+	if writer.Code != 200 || writer.Header().Get(CONTENT_TYPE_HEADER_KEY) != "application/json" {
+		t.Errorf("Expected 200 application/json, got %d %q", writer.Code, writer.Header().Get(CONTENT_TYPE_HEADER_KEY))
+	}
+	writer = serveObject(NewServer("").WithEncoder(MockFailingEncoder{}), MockCodecItem{"a", 1}, "application/x-failing")
+	if writer.Code != 500 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 500, writer.Code)
+	}
+}
+
+func TestObjectResponseEncodesTextAndCSV(t *testing.T) {
+	writer := serveObject(serverWithAllEncoders(), "hello", "text/plain")
+	if writer.Body.String() != "hello" || writer.Header().Get(CONTENT_TYPE_HEADER_KEY) != "text/plain; charset=utf-8" {
+		t.Errorf("Unexpected text response %q %q", writer.Header().Get(CONTENT_TYPE_HEADER_KEY), writer.Body.String())
+	}
+	writer = serveObject(serverWithAllEncoders(), []*MockCodecItem{{"a", 1}, nil, {"b,c", 2}}, "text/csv")
+	expected := "name,count\na,1\n,\n\"b,c\",2\n"
+	if writer.Body.String() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, writer.Body.String())
+	}
+	writer = serveObject(serverWithAllEncoders(), [][]any{{"a", 1}, {nil, true}}, "text/csv")
+	expected = "a,1\n,true\n"
+	if writer.Body.String() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, writer.Body.String())
+	}
+}
+
+func TestObjectResponseWritesNotAcceptable(t *testing.T) {
+	writer := serveObject(serverWithAllEncoders(), map[string]int{"a": 1}, "application/xml, text/csv")
+	if writer.Code != http.StatusNotAcceptable {
+		t.Errorf(EXPECTED_DIGIT_ERROR, http.StatusNotAcceptable, writer.Code)
+	}
+	expected := "not acceptable: available media types are application/json, application/cbor, application/msgpack"
+	if writer.Body.String() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, writer.Body.String())
+	}
+	writer = serveObject(NewServer(""), MockCodecItem{"a", 1}, "application/xml")
+	expected = "not acceptable: available media types are application/json"
+	if writer.Code != http.StatusNotAcceptable || writer.Body.String() != expected {
+		t.Errorf("Expected only JSON to be available by default, got %d %q", writer.Code, writer.Body.String())
+	}
+}
+
+func TestNotAcceptableWithoutAvailableTypes(t *testing.T) {
+	writer := httptest.NewRecorder()
+	NotAcceptable{}.Write(writer)
+	if writer.Code != http.StatusNotAcceptable || writer.Body.String() != "not acceptable" {
+		t.Errorf("Unexpected response %d %s", writer.Code, writer.Body.String())
+	}
+}
+
+func TestDefaultEncodersFollowJSONFieldRules(t *testing.T) {
+	for _, encoder := range defaultEncoders {
+		for _, value := range []any{MockSecretItem{"a", "hidden"}, MockMarshalerItem{"hidden"}} {
+			var buffer bytes.Buffer
+			if err := encoder.Encode(&buffer, value); err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Contains(buffer.Bytes(), []byte("hidden")) {
+				t.Errorf("Expected %s not to write fields hidden from JSON, got %q", encoder.MediaType(), buffer.String())
+			}
+		}
+	}
+}
+
+func TestWithEncoderAddsCustomEncoder(t *testing.T) {
+	server := NewServer("").WithEncoder(MockYAMLEncoder{})
+	writer := serveObject(server, 1, "application/yaml")
+	if writer.Body.String() != "value: 1" {
+		t.Errorf(EXPECTED_STRING_ERROR, "value: 1", writer.Body.String())
+	}
+	writer = serveObject(server, 1)
+	if writer.Header().Get(CONTENT_TYPE_HEADER_KEY) != CONTENT_TYPE_JSON {
+		t.Errorf("Expected custom encoders to be preferred after the defaults")
+	}
+	if len(defaultEncoders) != 1 {
+		t.Error("Expected default encoders to be left untouched")
+	}
+}
+
+func TestWithEncoderReplacesEncoderForSameMediaType(t *testing.T) {
+	server := NewServer("").WithEncoder(MockReplacementJSONEncoder{})
+	writer := serveObject(server, 1)
+	if writer.Body.String() != "replaced" {
+		t.Errorf(EXPECTED_STRING_ERROR, "replaced", writer.Body.String())
+	}
+	if len(server.settings.encoders) != len(defaultEncoders) {
+		t.Errorf(EXPECTED_DIGIT_ERROR, len(defaultEncoders), len(server.settings.encoders))
+	}
+}
+
+func TestEncodersRejectUnsupportedValues(t *testing.T) {
+	if (XMLEncoder{}).CanEncode(nil) || (XMLEncoder{}).CanEncode(map[string]int{}) {
+		t.Error("Expected XMLEncoder to reject nil and maps")
+	}
+	if !(XMLEncoder{}).CanEncode(&MockCodecItem{}) || (XMLEncoder{}).CanEncode(struct{ C chan int }{}) {
+		t.Error("Unexpected XMLEncoder support")
+	}
+	if (TextEncoder{}).CanEncode(MockCodecItem{}) || !(TextEncoder{}).CanEncode(fmt.Errorf("error")) {
+		t.Error("Unexpected TextEncoder support")
+	}
+	if err := (CSVEncoder{}).Encode(&bytes.Buffer{}, 1); err == nil {
+		t.Error("Expected CSVEncoder to reject non slices")
+	}
+	if err := (XMLEncoder{}).Encode(&bytes.Buffer{}, make(chan int)); err == nil {
+		t.Error("Expected XMLEncoder to return encoding errors")
+	}
+}
+
+func TestTextEncoderWritesBytesAsIs(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	(TextEncoder{}).Encode(buffer, []byte("raw"))
+	if buffer.String() != "raw" {
+		t.Errorf(EXPECTED_STRING_ERROR, "raw", buffer.String())
+	}
+}
+
+func TestIsEmptyValue(t *testing.T) {
+	cases := []struct {
+		value    any
+		expected bool
+	}{
+		{"", true},
+		{"a", false},
+		{[]int{}, true},
+		{[1]int{}, false},
+		{map[string]int{"a": 1}, false},
+		{false, true},
+		{1, false},
+		{uint8(0), true},
+		{0.0, true},
+		{(*int)(nil), true},
+		{MockCodecItem{}, false},
+	}
+	for _, c := range cases {
+		if actual := isEmptyValue(reflect.ValueOf(c.value)); actual != c.expected {
+			t.Errorf("Checking %#v: "+EXPECTED_STRING_ERROR, c.value, strconv.FormatBool(c.expected), strconv.FormatBool(actual))
+		}
+	}
+}
+
+func TestFormatCell(t *testing.T) {
+	uuid, _ := ParseUUID(MOCK_UUID)
+	text := "text"
+	cases := []struct {
+		value    any
+		expected string
+	}{
+		{nil, ""},
+		{(*string)(nil), ""},
+		{&text, "text"},
+		{1.5, "1.5"},
+		{uuid, MOCK_UUID},
+		{time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC), "2013-03-21T20:04:00Z"},
+		{MockFailingTextMarshaler{}, "{}"},
+	}
+	for _, c := range cases {
+		if actual := formatCell(reflect.ValueOf(c.value)); actual != c.expected {
+			t.Errorf("Formatting %#v: "+EXPECTED_STRING_ERROR, c.value, c.expected, actual)
+		}
+	}
+}
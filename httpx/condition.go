@@ -0,0 +1,128 @@
+package httpx
+
+import (
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Host restricts a route or a linked router to requests for the given host, such as "api.example.com".
+// Labels of the host may be wildcards, as in "{tenant}.example.com", whose values are available to handlers
+// as path parameters through Request.PathParam. Wildcards match a single label and may be constrained as
+// in a path, with constraints that do not contain a ".". Hosts are compared without their port, ignoring case.
+func Host(host string) RouteOption {
+	return func(info *RouteInfo) {
+		info.Host = host
+	}
+}
+
+// Header restricts a route or a linked router to requests with the given value for the header.
+// Header may be given more than once, in which case requests must have every value.
+func Header(key, value string) RouteOption {
+	return func(info *RouteInfo) {
+		if info.Headers == nil {
+			info.Headers = http.Header{}
+		}
+		info.Headers.Add(key, value)
+	}
+}
+
+// ContentType restricts a route or a linked router to requests whose body has one of the given media types,
+// such as "application/json", or "image/*" for any image. Requests for a path whose routes all have other
+// content types are answered with 415 Unsupported Media Type.
+func ContentType(types ...string) RouteOption {
+	return func(info *RouteInfo) {
+		info.ContentTypes = append(info.ContentTypes, types...)
+	}
+}
+
+// hostLabel is a label of a host pattern: a literal, or a wildcard if param is set.
+type hostLabel struct {
+	literal string
+	param   *pathParam
+}
+
+// parseHost parses a host pattern given to Host. It panics if the pattern is not valid.
+func parseHost(host string) []hostLabel {
+	var labels []hostLabel
+	for _, label := range strings.Split(host, ".") {
+		if param, ok := parseParam(label); ok && !param.remainder {
+			labels = append(labels, hostLabel{param: &param})
+			continue
+		}
+		if label == "" || strings.ContainsAny(label, "{}/: \t") {
+			panic(fmt.Sprintf("host %s is invalid", host))
+		}
+		labels = append(labels, hostLabel{literal: label})
+	}
+	return labels
+}
+
+// hostShape returns the host pattern with the names of its wildcards removed, which is the same for
+// patterns that match the same hosts.
+func hostShape(host string) string {
+	labels := strings.Split(strings.ToLower(host), ".")
+	for i, label := range labels {
+		if param, ok := parseParam(label); ok {
+			labels[i] = "{:" + param.constraint + "}"
+		}
+	}
+	return strings.Join(labels, ".")
+}
+
+// matchHost returns the values of the wildcards of the host pattern for the host of request,
+// and whether the host matches the pattern.
+func matchHost(labels []hostLabel, request *http.Request) ([]string, bool) {
+	host := request.Host
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	parts := strings.Split(strings.TrimSuffix(host, "."), ".")
+	if len(parts) != len(labels) {
+		return nil, false
+	}
+	var values []string
+	for i, label := range labels {
+		switch {
+		case label.param == nil:
+			if !strings.EqualFold(label.literal, parts[i]) {
+				return nil, false
+			}
+		case parts[i] == "" || label.param.matches != nil && !label.param.matches(parts[i]):
+			return nil, false
+		default:
+			values = append(values, parts[i])
+		}
+	}
+	return values, true
+}
+
+// matchHeaders reports whether request has every value of headers.
+func matchHeaders(headers http.Header, request *http.Request) bool {
+	for key, values := range headers {
+		for _, value := range values {
+			if !slices.Contains(request.Header.Values(key), value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matchContentType reports whether the body of request has one of the media types.
+func matchContentType(types []string, request *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	for _, accepted := range types {
+		accepted = strings.ToLower(accepted)
+		if accepted == mediaType || strings.HasSuffix(accepted, "/*") && strings.HasPrefix(mediaType, accepted[:len(accepted)-1]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package httpx
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func respondWith(body string) Handler {
+	return func(request Request) (Response, error) {
+		return RawResponse{StatusCode: 200, Body: []byte(body)}, nil
+	}
+}
+
+func serveBody(router *Router, method, target string, headers map[string]string) (int, string) {
+	request := httptest.NewRequest(method, target, nil)
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder.Code, recorder.Body.String()
+}
+
+func TestRoutesAreSelectedByHost(t *testing.T) {
+	router := NewRouter().
+		Route(GET, "/users/", respondWith("api"), Host("api.example.com")).
+		Route(GET, "/users/", respondWith("admin"), Host("Admin.Example.com"))
+	if _, body := serveBody(router, "GET", "http://api.example.com:8080/users/", nil); body != "api" {
+		t.Errorf(EXPECTED_STRING_ERROR, "api", body)
+	}
+	if _, body := serveBody(router, "GET", "http://admin.example.com/users/", nil); body != "admin" {
+		t.Errorf(EXPECTED_STRING_ERROR, "admin", body)
+	}
+	if code, _ := serveBody(router, "GET", "http://www.example.com/users/", nil); code != 404 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 404, code)
+	}
+}
+
+func TestHostParamsAreAvailableAsPathParams(t *testing.T) {
+	tenant := NewRouter().Route(GET, "/users/{id}/", func(request Request) (Response, error) {
+		return RawResponse{StatusCode: 200, Body: []byte(request.PathParam("tenant") + ":" + request.PathParam("id"))}, nil
+	})
+	router := NewRouter().Link("/api/", tenant, Host("{tenant}.example.com"))
+	if _, body := serveBody(router, "GET", "http://acme.example.com/api/users/42/", nil); body != "acme:42" {
+		t.Errorf(EXPECTED_STRING_ERROR, "acme:42", body)
+	}
+	if code, _ := serveBody(router, "GET", "http://example.com/api/users/42/", nil); code != 404 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 404, code)
+	}
+}
+
+func TestRoutersAreMergedPerTenant(t *testing.T) {
+	router := NewRouter().
+		Merge(NewRouter().Route(GET, "/home/", respondWith("a")), Host("a.example.com")).
+		Merge(NewRouter().Route(GET, "/home/", respondWith("b")), Host("b.example.com"))
+	if _, body := serveBody(router, "GET", "http://b.example.com/home/", nil); body != "b" {
+		t.Errorf(EXPECTED_STRING_ERROR, "b", body)
+	}
+}
+
+func TestRoutesAreSelectedByHeader(t *testing.T) {
+	router := NewRouter().
+		Route(GET, "/reports/", respondWith("v2"), Header("X-Version", "2")).
+		Route(GET, "/reports/", respondWith("v1"))
+	if _, body := serveBody(router, "GET", "/reports/", map[string]string{"X-Version": "2"}); body != "v2" {
+		t.Errorf(EXPECTED_STRING_ERROR, "v2", body)
+	}
+	if _, body := serveBody(router, "GET", "/reports/", nil); body != "v1" {
+		t.Errorf(EXPECTED_STRING_ERROR, "v1", body)
+	}
+}
+
+func TestRoutesAreSelectedByContentType(t *testing.T) {
+	router := NewRouter().
+		Route(POST, "/uploads/", respondWith("json"), ContentType("application/json")).
+		Route(POST, "/uploads/", respondWith("image"), ContentType("image/*"))
+	if _, body := serveBody(router, "POST", "/uploads/", map[string]string{CONTENT_TYPE_HEADER_KEY: "application/json; charset=utf-8"}); body != "json" {
+		t.Errorf(EXPECTED_STRING_ERROR, "json", body)
+	}
+	if _, body := serveBody(router, "POST", "/uploads/", map[string]string{CONTENT_TYPE_HEADER_KEY: "image/png"}); body != "image" {
+		t.Errorf(EXPECTED_STRING_ERROR, "image", body)
+	}
+	request := httptest.NewRequest("POST", "/uploads/", nil)
+	request.Header.Set(CONTENT_TYPE_HEADER_KEY, "text/plain")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != 415 || recorder.Header().Get("Accept") != "application/json, image/*" {
+		t.Errorf("Expected 415 accepting application/json, image/*, got %d %q", recorder.Code, recorder.Header().Get("Accept"))
+	}
+}
+
+func TestShadowedConditionsConflict(t *testing.T) {
+	router := NewRouter().Route(GET, "/users/", listUsers, Host("{tenant}.example.com")).Route(GET, "/users/", listUsers)
+	expectConflict(t, "route GET {name}.example.com/users/ (microx/httpx.listUsers) conflicts with route GET {tenant}.example.com/users/ (microx/httpx.listUsers)", func() {
+		router.Route(GET, "/users/", listUsers, Host("{name}.example.com"))
+	})
+	expectConflict(t, "router linked at a.example.com/ conflicts with router linked at a.example.com/", func() {
+		NewRouter().Merge(NewRouter(), Host("a.example.com")).Merge(NewRouter(), Host("a.example.com"))
+	})
+}
+
+func TestRoutesListsConditionsOfLinkedRouters(t *testing.T) {
+	inner := NewRouter().Route(POST, "/users/", listUsers, ContentType("application/json"), Header("X-Version", "2"))
+	router := NewRouter().Link("/api/", inner, Host("{tenant}.example.com"), Header("X-Client", "web"))
+	route := router.Routes()[0]
+	if route.Host != "{tenant}.example.com" || route.Headers.Get("X-Client") != "web" || route.Headers.Get("X-Version") != "2" || route.ContentTypes[0] != "application/json" {
+		t.Errorf("Expected the conditions of the route and its router, got %+v", route)
+	}
+}
+
+func TestInvalidHostsPanic(t *testing.T) {
+	for _, host := range []string{"api..example.com", "{id}.example.com"} {
+		t.Run(host, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error(PANIC_EXPECTED_ERROR)
+				}
+			}()
+			NewRouter().Route(GET, "/users/{id}/", listUsers, Host(host))
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package httpx
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// RouteConflictError reports a registration that matches requests already matched by another registration,
+// so that one of them would be ambiguous or never reached. Linked routers are described by a RouteInfo
+// with only the Path at which they are linked.
+type RouteConflictError struct {
+	Route    RouteInfo
+	Existing RouteInfo
+}
+
+func (err *RouteConflictError) Error() string {
+	return fmt.Sprintf("%s conflicts with %s", describeRoute(err.Route), describeRoute(err.Existing))
+}
+
+func describeRoute(info RouteInfo) string {
+	path := info.Host + info.Path
+	if info.Method == "" {
+		return "router linked at " + path
+	}
+	if info.Handler == "" {
+		return fmt.Sprintf("route %s %s", info.Method, path)
+	}
+	return fmt.Sprintf("route %s %s (%s)", info.Method, path, info.Handler)
+}
+
+// Validate reports every conflict between the registrations of the router and of the routers linked to it,
+// including routes added to linked routers after they were linked. Paths in the errors include the paths at
+// which routers are linked. Conflicts within a single router are also reported when they are registered.
+func (router *Router) Validate() error {
+	return router.collectConflicts("")
+}
+
+func (router *Router) collectConflicts(prefix string) error {
+	var errs []error
+	for i, entry := range router.entries {
+		for _, existing := range router.entries[:i] {
+			if err := conflict(entry, existing); err != nil {
+				err.Route.Path, err.Existing.Path = prefix+err.Route.Path, prefix+err.Existing.Path
+				errs = append(errs, err)
+			}
+		}
+		if entry.linked != nil {
+			errs = append(errs, entry.linked.collectConflicts(prefix+entry.info.Path[:len(entry.info.Path)-1]))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkConflicts panics with a RouteConflictError if entry conflicts with a registration of the router.
+func (router *Router) checkConflicts(entry routeEntry) {
+	for _, existing := range router.entries {
+		if err := conflict(entry, existing); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// conflict returns the conflict between two registrations of a router, or nil if there is none.
+// Routes with the same method conflict if their paths overlap without one being more specific, or if the existing
+// route accepts every request of the new one; HEAD routes are compared with GET routes in the same way.
+// Only routers linked at the same path with other conditions may match requests below a linked router,
+// and the routes of a merged router may be overridden by more specific routes, but not shadowed.
+func conflict(entry, existing routeEntry) *RouteConflictError {
+	if entry.isMerge() && existing.isMerge() {
+		if !shadows(existing.info, entry.info) {
+			return nil
+		}
+		return &RouteConflictError{Route: entry.info, Existing: existing.info}
+	}
+	if entry.isMerge() {
+		return conflictWithMerged(existing, entry, false)
+	}
+	if existing.isMerge() {
+		return conflictWithMerged(entry, existing, true)
+	}
+	relation := comparePatterns(entry.pattern(), existing.pattern())
+	conflicting := false
+	switch {
+	case relation == disjoint:
+	case entry.linked == nil && existing.linked == nil && entry.info.Method == existing.info.Method:
+		conflicting = relation == overlapping || relation == equivalent && shadows(existing.info, entry.info)
+	case entry.linked == nil && existing.linked == nil:
+		head := headRelation(entry.info.Method, existing.info.Method, relation)
+		conflicting = head == overlapping || head == moreGeneral
+	case entry.linked != nil && existing.linked != nil && relation == equivalent:
+		conflicting = shadows(existing.info, entry.info)
+	default:
+		conflicting = true
+	}
+	if !conflicting {
+		return nil
+	}
+	return &RouteConflictError{Route: entry.info, Existing: existing.info}
+}
+
+// headRelation returns the relation of the path of a HEAD route to that of a GET route, given the methods of two
+// routes and the relation of the path of the first to that of the second. It returns disjoint for other methods.
+func headRelation(first, second Method, relation relation) relation {
+	switch {
+	case first == HEAD && second == GET:
+		return relation
+	case first == GET && second == HEAD:
+		return relation.inverse()
+	}
+	return disjoint
+}
+
+// conflictWithMerged returns the conflict between entry and a route of the merged router, or nil if there is none.
+// If entryIsNew is set, entry is reported as the route being registered.
+func conflictWithMerged(entry, merged routeEntry, entryIsNew bool) *RouteConflictError {
+	pattern := entry.pattern()
+	for _, route := range merged.linked.Routes() {
+		if entry.linked == nil && entry.info.Method != route.Method {
+			continue
+		}
+		relation := comparePatterns(pattern, parsePattern(exactPattern(route.Path)))
+		if relation == disjoint || relation == moreSpecific {
+			continue
+		}
+		if entryIsNew {
+			return &RouteConflictError{Route: entry.info, Existing: route}
+		}
+		return &RouteConflictError{Route: route, Existing: entry.info}
+	}
+	return nil
+}
+
+func (entry routeEntry) isMerge() bool {
+	return entry.linked != nil && entry.info.Path == "/"
+}
+
+// pattern returns the pattern of the requests matched by the entry. Routes whose path ends in a "/" match
+// that path exactly, while linked routers match every path below theirs.
+func (entry routeEntry) pattern() pattern {
+	if entry.linked != nil {
+		return parsePattern(entry.info.Path)
+	}
+	return parsePattern(exactPattern(entry.info.Path))
+}
+
+// pattern is a parsed route path. Each segment is a literal, or a wildcard if wild is set.
+// A pattern with a remainder matches any path that continues below its segments, such as a path ending in "/"
+// or in a "{name...}" wildcard. The "{$}" wildcard is an empty final segment.
+type pattern struct {
+	segments  []patternSegment
+	remainder bool
+}
+
+type patternSegment struct {
+	literal string
+	wild    bool
+}
+
+func parsePattern(path string) pattern {
+	var parsed pattern
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	last := parts[len(parts)-1]
+	if param, ok := parseParam(last); last == "" || ok && param.remainder {
+		parts, parsed.remainder = parts[:len(parts)-1], true
+	} else if last == "{$}" {
+		parts[len(parts)-1] = ""
+	}
+	for _, part := range parts {
+		if _, ok := parseParam(part); ok {
+			parsed.segments = append(parsed.segments, patternSegment{wild: true})
+		} else {
+			parsed.segments = append(parsed.segments, patternSegment{literal: part})
+		}
+	}
+	return parsed
+}
+
+// matches reports whether the pattern matches path, an escaped request path. Segments are unescaped before
+// they are compared with literals, and wildcards match any segment that is not empty.
+func (pattern pattern) matches(path string) bool {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) < len(pattern.segments) || (len(parts) > len(pattern.segments)) != pattern.remainder {
+		return false
+	}
+	for i, segment := range pattern.segments {
+		if segment.wild {
+			if parts[i] == "" {
+				return false
+			}
+			continue
+		}
+		if literal, err := url.PathUnescape(parts[i]); err != nil || literal != segment.literal {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the pattern with its wildcards unnamed, which is the same for paths that match the same requests.
+func (pattern pattern) String() string {
+	var builder strings.Builder
+	for _, segment := range pattern.segments {
+		builder.WriteString("/")
+		if segment.wild {
+			builder.WriteString("{}")
+		} else {
+			builder.WriteString(segment.literal)
+		}
+	}
+	if pattern.remainder {
+		builder.WriteString("/...")
+	}
+	return builder.String()
+}
+
+// relation is how the requests matched by one pattern relate to those matched by another.
+type relation int
+
+const (
+	equivalent relation = iota
+	// moreSpecific patterns match a subset of the requests matched by the other pattern.
+	moreSpecific
+	// moreGeneral patterns match a superset of the requests matched by the other pattern.
+	moreGeneral
+	// overlapping patterns match some requests in common, but neither is more specific.
+	overlapping
+	disjoint
+)
+
+func (first relation) combine(second relation) relation {
+	switch {
+	case first == disjoint || second == disjoint:
+		return disjoint
+	case first == equivalent:
+		return second
+	case second == equivalent || first == second:
+		return first
+	default:
+		return overlapping
+	}
+}
+
+// inverse returns the relation of the second pattern to the first.
+func (first relation) inverse() relation {
+	switch first {
+	case moreSpecific:
+		return moreGeneral
+	case moreGeneral:
+		return moreSpecific
+	}
+	return first
+}
+
+// comparePatterns returns the relation of the requests matched by first to those matched by second.
+// Literal segments are more specific than wildcards, as in http.ServeMux.
+func comparePatterns(first, second pattern) relation {
+	result := equivalent
+	shared := min(len(first.segments), len(second.segments))
+	for i := 0; i < shared; i++ {
+		one, other := first.segments[i], second.segments[i]
+		switch {
+		case !one.wild && !other.wild && one.literal != other.literal:
+			return disjoint
+		case !one.wild && other.wild:
+			result = result.combine(moreSpecific)
+		case one.wild && !other.wild:
+			result = result.combine(moreGeneral)
+		}
+	}
+	switch {
+	case len(first.segments) == len(second.segments) && first.remainder != second.remainder:
+		return disjoint
+	case len(first.segments) < len(second.segments):
+		if !first.remainder {
+			return disjoint
+		}
+		return result.combine(moreGeneral)
+	case len(first.segments) > len(second.segments):
+		if !second.remainder {
+			return disjoint
+		}
+		return result.combine(moreSpecific)
+	}
+	return result
+}
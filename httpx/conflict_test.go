@@ -0,0 +1,230 @@
+package httpx
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func expectConflict(t *testing.T, expected string, register func()) {
+	t.Helper()
+	defer func() {
+		recovered := recover()
+		err, ok := recovered.(*RouteConflictError)
+		if !ok {
+			t.Fatalf("Expected a RouteConflictError, got %v", recovered)
+		}
+		if err.Error() != expected {
+			t.Errorf(EXPECTED_STRING_ERROR, expected, err.Error())
+		}
+	}()
+	register()
+}
+
+func TestDuplicateRouteNamesBothRoutes(t *testing.T) {
+	router := NewRouter().Route(GET, "/users/{id}/", listUsers)
+	expectConflict(t, "route GET /users/{name}/ (microx/httpx.listUsers) conflicts with route GET /users/{id}/ (microx/httpx.listUsers)", func() {
+		router.Route(GET, "/users/{name}/", listUsers)
+	})
+}
+
+func TestOverlappingRoutesConflict(t *testing.T) {
+	router := NewRouter().Route(GET, "/users/{id}/edit/", listUsers)
+	expectConflict(t, "route GET /{kind}/new/edit/ (microx/httpx.listUsers) conflicts with route GET /users/{id}/edit/ (microx/httpx.listUsers)", func() {
+		router.Route(GET, "/{kind}/new/edit/", listUsers)
+	})
+}
+
+func TestMoreSpecificRoutesDoNotConflict(t *testing.T) {
+	handler, details := CreateMockHandler()
+	router := NewRouter().Route(GET, "/users/{id}/", listUsers).Route(GET, "/users/new/", handler).Route(POST, "/users/{id}/", listUsers)
+	router.ServeHTTP(httptest.NewRecorder(), CreateMockHTTPRequest(GET, "/users/new/"))
+	if details.HandlerCallCount != 1 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 1, details.HandlerCallCount)
+	}
+}
+
+func TestRoutesWithDifferentParameterNamesShareOptions(t *testing.T) {
+	router := NewRouter().Route(GET, "/users/{id}/", listUsers).Route(DELETE, "/users/{name}/", listUsers)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(OPTIONS, "/users/1/"))
+	if recorder.Header().Get("Allow") != "GET, DELETE, HEAD, OPTIONS" {
+		t.Errorf(EXPECTED_STRING_ERROR, "GET, DELETE, HEAD, OPTIONS", recorder.Header().Get("Allow"))
+	}
+}
+
+func TestRoutesWithOtherMethodsDoNotConflict(t *testing.T) {
+	router := NewRouter().Route(GET, "/a/{x}/", respondWith("get")).Route(POST, "/{y}/b/", respondWith("post"))
+	if err := router.Validate(); err != nil {
+		t.Errorf("Expected no conflicts, got %v", err)
+	}
+	if _, body := serveBody(router, "POST", "/a/b/", nil); body != "post" {
+		t.Errorf(EXPECTED_STRING_ERROR, "post", body)
+	}
+}
+
+func TestHeadRoutesConflictWithOverlappingGetRoutes(t *testing.T) {
+	router := NewRouter().Route(GET, "/a/{x}/", listUsers).Route(GET, "/users/new/", listUsers)
+	expectConflict(t, "route HEAD /{y}/b/ (microx/httpx.listUsers) conflicts with route GET /a/{x}/ (microx/httpx.listUsers)", func() {
+		router.Route(HEAD, "/{y}/b/", listUsers)
+	})
+	expectConflict(t, "route HEAD /users/{id}/ (microx/httpx.listUsers) conflicts with route GET /users/new/ (microx/httpx.listUsers)", func() {
+		router.Route(HEAD, "/users/{id}/", listUsers)
+	})
+	router.Route(HEAD, "/a/{y}/", listUsers).Route(HEAD, "/users/new/", listUsers)
+	head := NewRouter().Route(HEAD, "/a/{y}/", listUsers)
+	expectConflict(t, "route GET /{z}/c/ (microx/httpx.listUsers) conflicts with route HEAD /a/{y}/ (microx/httpx.listUsers)", func() {
+		head.Route(GET, "/{z}/c/", listUsers)
+	})
+}
+
+func TestDuplicateParameterNamesPanic(t *testing.T) {
+	defer func() {
+		if recovered := recover(); recovered == nil || !strings.Contains(recovered.(string), "parameter id") {
+			t.Errorf("Expected panic naming the parameter, got %v", recovered)
+		}
+	}()
+	NewRouter().Route(GET, "/users/{id}/posts/{id}/", listUsers)
+}
+
+func TestRouteBelowLinkedRouterConflicts(t *testing.T) {
+	router := NewRouter().Link(MOCK_LINK, NewRouter().Route(GET, MOCK_PATH, listUsers))
+	expectConflict(t, "route GET /link/path/ (microx/httpx.listUsers) conflicts with router linked at /link/", func() {
+		router.Route(GET, MOCK_LINKED_PATH, listUsers)
+	})
+	expectConflict(t, "router linked at /link/nested/ conflicts with router linked at /link/", func() {
+		router.Link("/link/nested/", NewRouter())
+	})
+}
+
+func TestLinkAboveRouteConflicts(t *testing.T) {
+	router := NewRouter().Route(GET, "/{section}/users/", listUsers)
+	expectConflict(t, "router linked at /admin/ conflicts with route GET /{section}/users/ (microx/httpx.listUsers)", func() {
+		router.Link("/admin/", NewRouter())
+	})
+}
+
+func TestCatchAllRouteConflictsWithLinks(t *testing.T) {
+	router := NewRouter().Static("/", fstest.MapFS{})
+	expectConflict(t, "router linked at /api/ conflicts with route GET /{file...} (Static)", func() {
+		router.Link("/api/", NewRouter())
+	})
+}
+
+func TestMergedRoutesMustNotBeShadowed(t *testing.T) {
+	router := NewRouter().Merge(NewRouter().Route(GET, "/users/{id}/", listUsers))
+	router.Route(GET, "/users/me/", listUsers)
+	expectConflict(t, "route GET /users/{rest...} (microx/httpx.listUsers) conflicts with route GET /users/{id}/ (microx/httpx.listUsers)", func() {
+		router.Route(GET, "/users/{rest...}", listUsers)
+	})
+	expectConflict(t, "router linked at / conflicts with router linked at /", func() {
+		router.Merge(NewRouter())
+	})
+}
+
+func TestValidateReportsConflictsAcrossLinkedRouters(t *testing.T) {
+	merged, extra := NewRouter(), NewRouter()
+	v1 := NewRouter().Merge(extra).Route(GET, "/status/", listUsers)
+	router := NewRouter().Route(GET, "/health/", listUsers).Merge(merged).Link("/v1/", v1)
+	if err := router.Validate(); err != nil {
+		t.Fatalf("Expected no conflicts, got %v", err)
+	}
+
+	merged.Route(GET, "/health/", listUsers)
+	extra.Route(GET, "/status/", listUsers)
+	err := router.Validate()
+	var conflict *RouteConflictError
+	if !errors.As(err, &conflict) || conflict.Route.Path != "/health/" {
+		t.Fatalf("Expected a conflict for /health/, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "route GET /v1/status/ (microx/httpx.listUsers) conflicts with route GET /v1/status/") {
+		t.Errorf("Expected the conflict in the linked router to include its prefix, got %v", err)
+	}
+}
+
+func TestRoutersLinkedWithTheSameConditionsConflict(t *testing.T) {
+	router := NewRouter().Link("/api/", NewRouter(), Host("a.example.com"))
+	router.Link("/api/", NewRouter(), Host("b.example.com"))
+	expectConflict(t, "router linked at a.example.com/api/ conflicts with router linked at a.example.com/api/", func() {
+		router.Link("/api/", NewRouter(), Host("a.example.com"))
+	})
+}
+
+func TestRouteConflictErrorsDescribeRoutesWithoutHandlers(t *testing.T) {
+	err := &RouteConflictError{Route: RouteInfo{Method: GET, Path: "/users/"}, Existing: RouteInfo{Path: "/"}}
+	expected := "route GET /users/ conflicts with router linked at /"
+	if err.Error() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, err.Error())
+	}
+}
+
+func TestPatternWildcardsDoNotMatchEmptySegments(t *testing.T) {
+	pattern := parsePattern("/users/{id}/")
+	if !pattern.matches("/users/1/") {
+		t.Errorf("Expected %s to match /users/1/", "/users/{id}/")
+	}
+	if pattern.matches("/users//") {
+		t.Errorf("Expected %s not to match /users//", "/users/{id}/")
+	}
+}
+
+func TestComparePatterns(t *testing.T) {
+	cases := []struct {
+		first, second string
+		expected      relation
+	}{
+		{"/users/{id}/", "/users/{name}/", equivalent},
+		{"/users/new/", "/users/{id}/", moreSpecific},
+		{"/users/", "/users/{id}/", moreGeneral},
+		{"/users/{id}/", "/{kind}/new/", overlapping},
+		{"/users/", "/posts/", disjoint},
+		{"/files/{path...}", "/files/", equivalent},
+		{"/files/{$}", "/files/", moreSpecific},
+		{"/files/{$}", "/files/{name}/", disjoint},
+		{"/{$}", "/{kind}/{id}/", disjoint},
+		{"/{kind}/{id}/", "/{$}", disjoint},
+	}
+	for _, c := range cases {
+		if actual := comparePatterns(parsePattern(c.first), parsePattern(c.second)); actual != c.expected {
+			t.Errorf("Expected %s to %s to be %d, got %d", c.first, c.second, c.expected, actual)
+		}
+	}
+}
+
+func TestCombineRelations(t *testing.T) {
+	cases := []struct {
+		first, second, expected relation
+	}{
+		{equivalent, moreSpecific, moreSpecific},
+		{moreGeneral, equivalent, moreGeneral},
+		{moreSpecific, moreSpecific, moreSpecific},
+		{moreSpecific, moreGeneral, overlapping},
+		{overlapping, moreGeneral, overlapping},
+		{disjoint, moreSpecific, disjoint},
+		{equivalent, disjoint, disjoint},
+	}
+	for _, c := range cases {
+		if actual := c.first.combine(c.second); actual != c.expected {
+			t.Errorf("Expected %d combined with %d to be %d, got %d", c.first, c.second, c.expected, actual)
+		}
+	}
+}
+
+func TestInverseRelations(t *testing.T) {
+	cases := []struct {
+		relation, expected relation
+	}{
+		{equivalent, equivalent},
+		{moreSpecific, moreGeneral},
+		{moreGeneral, moreSpecific},
+		{overlapping, overlapping},
+		{disjoint, disjoint},
+	}
+	for _, c := range cases {
+		if actual := c.relation.inverse(); actual != c.expected {
+			t.Errorf("Expected the inverse of %d to be %d, got %d", c.relation, c.expected, actual)
+		}
+	}
+}
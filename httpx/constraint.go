@@ -0,0 +1,258 @@
+package httpx
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// pathParam is a wildcard of a route path, such as {id}, {id:int} or {path...}.
+type pathParam struct {
+	name string
+	// constraint is the constraint given after the name, if any.
+	constraint string
+	// matches reports whether a value satisfies the constraint. It is nil for unconstrained wildcards.
+	matches func(value string) bool
+	// remainder is set for {name...} wildcards, which match the rest of the path.
+	remainder bool
+}
+
+// parseParam parses a segment of a route path as a wildcard. It reports false for literal segments and {$}.
+// It panics if the constraint of the wildcard is not a valid regular expression.
+func parseParam(segment string) (pathParam, bool) {
+	if len(segment) < 2 || segment[0] != '{' || segment[len(segment)-1] != '}' || segment == "{$}" {
+		return pathParam{}, false
+	}
+	inner := segment[1 : len(segment)-1]
+	if strings.HasSuffix(inner, "...") && !strings.Contains(inner, ":") {
+		return pathParam{name: strings.TrimSuffix(inner, "..."), remainder: true}, true
+	}
+	name, constraint, _ := strings.Cut(inner, ":")
+	param := pathParam{name: name, constraint: constraint}
+	switch constraint {
+	case "":
+	case "int":
+		param.matches = func(value string) bool {
+			_, err := strconv.Atoi(value)
+			return err == nil
+		}
+	case "uuid":
+		param.matches = func(value string) bool {
+			_, err := ParseUUID(value)
+			return err == nil
+		}
+	default:
+		expression, err := regexp.Compile(`^(?:` + constraint + `)$`)
+		if err != nil {
+			panic(fmt.Sprintf("path parameter %s has an invalid constraint: %s", name, err))
+		}
+		param.matches = expression.MatchString
+	}
+	return param, true
+}
+
+// parseParams returns the wildcards of a route path, in order.
+func parseParams(path string) []pathParam {
+	var params []pathParam
+	for _, segment := range strings.Split(path, "/") {
+		if param, ok := parseParam(segment); ok {
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// stripConstraints returns path with the constraints of its wildcards removed, as a pattern for http.ServeMux.
+func stripConstraints(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if param, ok := parseParam(segment); ok && param.constraint != "" {
+			segments[i] = "{" + param.name + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// shadows reports whether every request accepted by the constraints and conditions of the route described by
+// info is also accepted by those of existing, a route whose path matches the same requests. A route registered
+// after existing is then never reached.
+func shadows(existing, info RouteInfo) bool {
+	params := parseParams(info.Path)
+	for i, param := range parseParams(existing.Path) {
+		if param.constraint != "" && param.constraint != params[i].constraint {
+			return false
+		}
+	}
+	if existing.Host != "" && hostShape(existing.Host) != hostShape(info.Host) {
+		return false
+	}
+	for key, values := range existing.Headers {
+		for _, value := range values {
+			if !slices.Contains(info.Headers.Values(key), value) {
+				return false
+			}
+		}
+	}
+	if len(existing.ContentTypes) == 0 {
+		return true
+	}
+	for _, contentType := range info.ContentTypes {
+		if !slices.Contains(existing.ContentTypes, contentType) {
+			return false
+		}
+	}
+	return len(info.ContentTypes) > 0
+}
+
+// candidate is one of the routes registered for a method and for paths that match the same requests,
+// which differ in the constraints of their wildcards or in their conditions.
+type candidate struct {
+	params []pathParam
+	// names are the names of the wildcards in the pattern registered with the multiplexer.
+	names []string
+	// renamed is set if the names of the wildcards of the candidate differ from names.
+	renamed bool
+	// host is the host pattern of the candidate, if any.
+	host         []hostLabel
+	headers      http.Header
+	contentTypes []string
+	handler      http.Handler
+}
+
+// candidateMatch is the outcome of matching a request against a candidate.
+type candidateMatch int
+
+const (
+	matched candidateMatch = iota
+	mismatched
+	// unsupportedContentType is the outcome for requests that only fail to match the content types of the candidate.
+	unsupportedContentType
+)
+
+func newCandidate(info RouteInfo, pattern string, handler http.Handler) candidate {
+	var names []string
+	for _, param := range parseParams(pattern) {
+		names = append(names, param.name)
+	}
+	params := parseParams(info.Path)
+	renamed := false
+	for i, param := range params {
+		renamed = renamed || param.name != names[i]
+	}
+	created := candidate{params: params, names: names, renamed: renamed, headers: info.Headers, contentTypes: info.ContentTypes, handler: handler}
+	if info.Host != "" {
+		created.host = parseHost(info.Host)
+		for _, label := range created.host {
+			if label.param != nil && slices.ContainsFunc(params, func(param pathParam) bool { return param.name == label.param.name }) {
+				panic(fmt.Sprintf("host %s is invalid: parameter %s is also used in path %s", info.Host, label.param.name, info.Path))
+			}
+		}
+	}
+	return created
+}
+
+// satisfies reports whether values, the values of the wildcards of the candidate in order, satisfy their constraints.
+func (candidate candidate) satisfies(values []string) bool {
+	for i, param := range candidate.params {
+		if param.matches != nil && !param.matches(values[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// pathValues returns the unescaped values of the wildcards of pattern in path, an escaped request path that
+// pattern matches, in the order of the wildcards.
+func pathValues(pattern string, path string) []string {
+	var values []string
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, segment := range strings.Split(strings.TrimPrefix(pattern, "/"), "/") {
+		param, ok := parseParam(segment)
+		if !ok {
+			continue
+		}
+		value := parts[i]
+		if param.remainder {
+			value = strings.Join(parts[i:], "/")
+		}
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			value = unescaped
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// match reports whether request satisfies the constraints and conditions of the candidate.
+// If it does, the values of the wildcards of the candidate are made available under their names.
+func (candidate candidate) match(request *http.Request) candidateMatch {
+	for i, param := range candidate.params {
+		if param.matches != nil && !param.matches(request.PathValue(candidate.names[i])) {
+			return mismatched
+		}
+	}
+	var hostValues []string
+	if candidate.host != nil {
+		values, ok := matchHost(candidate.host, request)
+		if !ok {
+			return mismatched
+		}
+		hostValues = values
+	}
+	if !matchHeaders(candidate.headers, request) {
+		return mismatched
+	}
+	if candidate.contentTypes != nil && !matchContentType(candidate.contentTypes, request) {
+		return unsupportedContentType
+	}
+	if candidate.renamed {
+		values := make([]string, len(candidate.names))
+		for i, name := range candidate.names {
+			values[i] = request.PathValue(name)
+		}
+		for i, param := range candidate.params {
+			request.SetPathValue(param.name, values[i])
+		}
+	}
+	for _, label := range candidate.host {
+		if label.param != nil {
+			request.SetPathValue(label.param.name, hostValues[0])
+			hostValues = hostValues[1:]
+		}
+	}
+	return matched
+}
+
+// matchCandidate returns the first of candidates that matches request, or nil if none does.
+func matchCandidate(candidates []candidate, request *http.Request) http.Handler {
+	for _, candidate := range candidates {
+		if candidate.match(request) == matched {
+			return candidate.handler
+		}
+	}
+	return nil
+}
+
+// serveCandidates serves request with the first of candidates that matches it. Requests that match none are
+// answered as not found, or as unsupported if they only fail to match the content types of candidates.
+func serveCandidates(candidates []candidate, writer http.ResponseWriter, request *http.Request) {
+	var supported []string
+	for _, candidate := range candidates {
+		switch candidate.match(request) {
+		case matched:
+			candidate.handler.ServeHTTP(writer, request)
+			return
+		case unsupportedContentType:
+			supported = append(supported, candidate.contentTypes...)
+		}
+	}
+	if supported != nil {
+		adapt(func(Request) (Response, error) { return UnsupportedMediaType{Supported: supported}, nil }).ServeHTTP(writer, request)
+		return
+	}
+	serveNotFound(writer, request)
+}
@@ -0,0 +1,134 @@
+package httpx
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func recordParam(name string, values *[]string) Handler {
+	return func(request Request) (Response, error) {
+		*values = append(*values, name+"="+request.PathParam(name))
+		return NoContent{}, nil
+	}
+}
+
+func TestValidateAcceptsConstraintsAndRemainders(t *testing.T) {
+	for _, path := range []string{"/users/{id:int}/", "/users/{id:uuid}/", "/posts/{slug:[a-z-]+}/", "/files/{path...}", "/{path...}"} {
+		validate(path)
+	}
+}
+
+func TestValidateRejectsInvalidWildcards(t *testing.T) {
+	for _, path := range []string{"/files/{path...}/", "/files/{path...}/more/", "/users/{id:[0-9}/", "/users/{1d}/", "/users/{id:}/"} {
+		t.Run(path, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error(PANIC_EXPECTED_ERROR)
+				}
+			}()
+			validate(path)
+		})
+	}
+}
+
+func TestConstrainedRoutesAreTriedInOrder(t *testing.T) {
+	var values []string
+	router := NewRouter().
+		Route(GET, "/users/{id:int}/", recordParam("id", &values)).
+		Route(GET, "/users/{uuid:uuid}/", recordParam("uuid", &values)).
+		Route(GET, "/users/{slug:[a-z-]+}/", recordParam("slug", &values))
+	for _, path := range []string{"/users/42/", "/users/6ba7b810-9dad-11d1-80b4-00c04fd430c8/", "/users/jane-doe/"} {
+		router.ServeHTTP(httptest.NewRecorder(), CreateMockHTTPRequest(GET, path))
+	}
+	expected := []string{"id=42", "uuid=6ba7b810-9dad-11d1-80b4-00c04fd430c8", "slug=jane-doe"}
+	if len(values) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, values)
+	}
+	for i := range expected {
+		if values[i] != expected[i] {
+			t.Errorf(EXPECTED_STRING_ERROR, expected[i], values[i])
+		}
+	}
+}
+
+func TestUnsatisfiedConstraintsAreNotFound(t *testing.T) {
+	handler, details := CreateMockHandler()
+	router := NewRouter().Route(GET, "/users/{id:int}/", handler)
+	router.NotFound(func(Request) (Response, error) { return RawResponse{StatusCode: 404, Body: []byte("custom")}, nil })
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(GET, "/users/jane/"))
+	if recorder.Code != 404 || recorder.Body.String() != "custom" || details.HandlerCallCount != 0 {
+		t.Errorf("Expected custom 404 without calling the handler, got %d %q", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestUnsatisfiedConstraintsAreNotFoundForOtherMethods(t *testing.T) {
+	handler, _ := CreateMockHandler()
+	router := NewRouter().
+		Route(GET, "/items/{id:int}/", handler).
+		Route(GET, "/items/{slug:[a-z-]+}/", handler)
+	for _, method := range []Method{GET, POST, OPTIONS} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, CreateMockHTTPRequest(method, "/items/AB/"))
+		if recorder.Code != 404 {
+			t.Errorf("Expected 404 for %s, got %d", method, recorder.Code)
+		}
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(POST, "/items/ab/"))
+	if recorder.Code != 405 || recorder.Header().Get("Allow") != "GET, HEAD, OPTIONS" {
+		t.Errorf("Expected 405 with Allow GET, HEAD, OPTIONS, got %d %q", recorder.Code, recorder.Header().Get("Allow"))
+	}
+}
+
+func TestUnconstrainedRouteIsFallback(t *testing.T) {
+	var values []string
+	router := NewRouter().
+		Route(GET, "/users/{id:int}/", recordParam("id", &values)).
+		Route(GET, "/users/{name}/", recordParam("name", &values))
+	router.ServeHTTP(httptest.NewRecorder(), CreateMockHTTPRequest(GET, "/users/7/"))
+	router.ServeHTTP(httptest.NewRecorder(), CreateMockHTTPRequest(GET, "/users/jane/"))
+	if len(values) != 2 || values[0] != "id=7" || values[1] != "name=jane" {
+		t.Errorf("Expected id=7 and name=jane, got %v", values)
+	}
+}
+
+func TestShadowedConstrainedRoutesConflict(t *testing.T) {
+	router := NewRouter().Route(GET, "/users/{id:int}/", listUsers).Route(GET, "/posts/{id}/", listUsers)
+	expectConflict(t, "route GET /users/{n:int}/ (microx/httpx.listUsers) conflicts with route GET /users/{id:int}/ (microx/httpx.listUsers)", func() {
+		router.Route(GET, "/users/{n:int}/", listUsers)
+	})
+	expectConflict(t, "route GET /posts/{id:int}/ (microx/httpx.listUsers) conflicts with route GET /posts/{id}/ (microx/httpx.listUsers)", func() {
+		router.Route(GET, "/posts/{id:int}/", listUsers)
+	})
+}
+
+func TestRemainderWildcardMatchesRestOfPath(t *testing.T) {
+	var values []string
+	router := NewRouter().Route(GET, "/files/{path...}", recordParam("path", &values))
+	router.ServeHTTP(httptest.NewRecorder(), CreateMockHTTPRequest(GET, "/files/docs/guide.md"))
+	if len(values) != 1 || values[0] != "path=docs/guide.md" {
+		t.Errorf("Expected path=docs/guide.md, got %v", values)
+	}
+}
+
+func TestConstrainedPrefixesPanic(t *testing.T) {
+	for _, path := range []string{"/users/{id:int}/", "/files/{path...}"} {
+		t.Run(path, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error(PANIC_EXPECTED_ERROR)
+				}
+			}()
+			NewRouter().Link(path, NewRouter())
+		})
+	}
+}
+
+func TestURLChecksConstraints(t *testing.T) {
+	router := NewRouter().Route(GET, "/users/{id:int}/", listUsers, Name("user"))
+	if url := router.URL("user", "id", "42"); url != "/users/42/" {
+		t.Errorf(EXPECTED_STRING_ERROR, "/users/42/", url)
+	}
+	expectURLPanic(t, func() { router.URL("user", "id", "jane") })
+}
@@ -0,0 +1,149 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+)
+
+// settings is the server wide configuration consulted by responses while they are written.
+type settings struct {
+	// problemDetails renders error responses as application/problem+json.
+	problemDetails bool
+	// errorMappers convert handler errors to responses.
+	errorMappers []ErrorMapper
+	// panicReporter is called with panics recovered from handlers. Nil reports to slog.Default.
+	panicReporter PanicReporter
+	// propagateAbortHandler re-panics http.ErrAbortHandler instead of recovering from it.
+	propagateAbortHandler bool
+	// encoders are the encoders available to content negotiation, in order of preference.
+	encoders []Encoder
+}
+
+var defaultSettings = settings{encoders: defaultEncoders}
+
+type settingsKey struct{}
+
+// withSettings returns a copy of ctx that carries the given settings.
+func withSettings(ctx context.Context, settings *settings) context.Context {
+	return context.WithValue(ctx, settingsKey{}, settings)
+}
+
+// settingsFrom returns the settings carried by ctx, or the defaults if there are none.
+func settingsFrom(ctx context.Context) *settings {
+	if settings, ok := ctx.Value(settingsKey{}).(*settings); ok {
+		return settings
+	}
+	return &defaultSettings
+}
+
+// routerScope is a link in the chain of routers handling a request, from the innermost router outwards.
+type routerScope struct {
+	router *Router
+	// path is the escaped path of the request as the router received it, before any linked router's prefix is stripped.
+	path   string
+	parent *routerScope
+}
+
+// routing holds the chain of routers handling a request. It is shared by the routers the request passes
+// through, so that only the outermost router copies the request to carry it.
+type routing struct {
+	scope *routerScope
+	// scopes backs the innermost scopes of the chain, so that entering a linked router does not allocate.
+	scopes [4]routerScope
+	depth  int
+}
+
+type routingKey struct{}
+
+// withRouting returns a copy of ctx that carries state.
+func withRouting(ctx context.Context, state *routing) context.Context {
+	return context.WithValue(ctx, routingKey{}, state)
+}
+
+// routingFrom returns the routing state carried by ctx, or nil if there is none.
+func routingFrom(ctx context.Context) *routing {
+	state, _ := ctx.Value(routingKey{}).(*routing)
+	return state
+}
+
+// enter makes router the innermost router handling the request, which it received for the escaped path.
+func (state *routing) enter(router *Router, path string) {
+	var scope *routerScope
+	if state.depth < len(state.scopes) {
+		scope = &state.scopes[state.depth]
+	} else {
+		scope = new(routerScope)
+	}
+	*scope = routerScope{router, path, state.scope}
+	state.scope = scope
+	state.depth++
+}
+
+// leave restores the router that entered before the innermost one.
+func (state *routing) leave() {
+	state.scope = state.scope.parent
+	state.depth--
+}
+
+// routerScopeFrom returns the innermost router scope carried by ctx, or nil if there is none.
+func routerScopeFrom(ctx context.Context) *routerScope {
+	if state := routingFrom(ctx); state != nil {
+		return state.scope
+	}
+	return nil
+}
+
+type allowedMethodsKey struct{}
+
+// withAllowedMethods returns a copy of ctx that carries the methods allowed for the path of a request.
+func withAllowedMethods(ctx context.Context, methods []Method) context.Context {
+	return context.WithValue(ctx, allowedMethodsKey{}, methods)
+}
+
+// requestWriter is the ResponseWriter that adapt hands to responses.
+// It gives responses access to the request being answered.
+type requestWriter struct {
+	http.ResponseWriter
+	request *http.Request
+}
+
+// Unwrap returns the underlying http.ResponseWriter.
+//
+// see http.ResponseController for more details.
+func (writer *requestWriter) Unwrap() http.ResponseWriter {
+	return writer.ResponseWriter
+}
+
+// requestOf returns the request being answered through writer, or nil if writer was not created by adapt.
+func requestOf(writer ResponseWriter) *http.Request {
+	if writer, ok := writer.(*requestWriter); ok {
+		return writer.request
+	}
+	return nil
+}
+
+// requestContext returns the context of the request being answered through writer,
+// or context.Background if writer was not created by adapt.
+func requestContext(writer ResponseWriter) context.Context {
+	if request := requestOf(writer); request != nil {
+		return request.Context()
+	}
+	return context.Background()
+}
+
+// flush sends any buffered data to the client, if the writer supports it.
+//
+// see http.ResponseController.Flush for more details.
+func flush(writer ResponseWriter) {
+	if httpWriter, ok := writer.(http.ResponseWriter); ok {
+		http.NewResponseController(httpWriter).Flush()
+	}
+}
+
+// settingsOf returns the settings that apply to responses written to writer.
+func settingsOf(writer ResponseWriter) *settings {
+	if request := requestOf(writer); request != nil {
+		return settingsFrom(request.Context())
+	}
+	return &defaultSettings
+}
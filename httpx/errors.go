@@ -0,0 +1,73 @@
+package httpx
+
+import (
+	"errors"
+	"fmt"
+)
+
+// HTTPError is implemented by errors that declare how they are presented to clients.
+// When returned from a Handler, they are written as an ErrorResponse with the given status code and message.
+// The error itself is not exposed.
+type HTTPError interface {
+	error
+	StatusCode() int
+	PublicMessage() string
+}
+
+// ErrorMapper converts an error returned by a Handler to a Response.
+// It reports false if it does not handle the error.
+type ErrorMapper = func(error) (Response, bool)
+
+// ErrorIs returns an ErrorMapper that handles errors matching target according to errors.Is.
+func ErrorIs(target error, response func(error) Response) ErrorMapper {
+	return func(err error) (Response, bool) {
+		if errors.Is(err, target) {
+			return response(err), true
+		}
+		return nil, false
+	}
+}
+
+// ErrorAs returns an ErrorMapper that handles errors which errors.As can assign to a T.
+func ErrorAs[T error](response func(T) Response) ErrorMapper {
+	return func(err error) (Response, bool) {
+		var target T
+		if errors.As(err, &target) {
+			return response(target), true
+		}
+		return nil, false
+	}
+}
+
+// responseFor returns the response for an error returned by a handler serving a request with the given context.
+// The error mappers of the routers handling the request are consulted first, from the innermost router outwards,
+// followed by those of the server. Failing that, errors that are themselves a Response are written as is and
+// HTTPErrors are written as an ErrorResponse. Any other error results in an InternalServerError.
+func responseFor(scope *routerScope, settings *settings, err error) Response {
+	for ; scope != nil; scope = scope.parent {
+		if response, ok := mapError(scope.router.errorMappers, err); ok {
+			return response
+		}
+	}
+	if response, ok := mapError(settings.errorMappers, err); ok {
+		return response
+	}
+	var response Response
+	if errors.As(err, &response) {
+		return response
+	}
+	var httpErr HTTPError
+	if errors.As(err, &httpErr) {
+		return ErrorResponse{StatusCode: httpErr.StatusCode(), Message: httpErr.PublicMessage()}
+	}
+	return InternalServerError{fmt.Errorf("internal server error")}
+}
+
+func mapError(mappers []ErrorMapper, err error) (Response, bool) {
+	for _, mapper := range mappers {
+		if response, ok := mapper(err); ok {
+			return response, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,147 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var ErrMockNotFound = errors.New("not found")
+
+type MockHTTPError struct{}
+
+func (MockHTTPError) Error() string         { return "secret details" }
+func (MockHTTPError) StatusCode() int       { return 409 }
+func (MockHTTPError) PublicMessage() string { return "conflict" }
+
+type MockTypedError struct{ Code int }
+
+func (err *MockTypedError) Error() string { return fmt.Sprintf("code %d", err.Code) }
+
+func MockNotFoundResponse(error) Response {
+	return ErrorResponse{StatusCode: 404, Message: "not found"}
+}
+
+func CreateReturningMockHandler(err error) Handler {
+	return func(Request) (Response, error) {
+		return nil, err
+	}
+}
+
+func serveError(router *Router, err error) *httptest.ResponseRecorder {
+	router.Route(GET, MOCK_PATH, CreateReturningMockHandler(err))
+	writer := httptest.NewRecorder()
+	router.ServeHTTP(writer, CreateMockHTTPRequest(GET, MOCK_PATH))
+	return writer
+}
+
+func TestErrorIsMatchesWrappedSentinels(t *testing.T) {
+	mapper := ErrorIs(ErrMockNotFound, MockNotFoundResponse)
+	if _, ok := mapper(fmt.Errorf("loading: %w", ErrMockNotFound)); !ok {
+		t.Error("Expected wrapped sentinel to match")
+	}
+	if _, ok := mapper(fmt.Errorf("other")); ok {
+		t.Error("Expected other error not to match")
+	}
+}
+
+func TestErrorAsMatchesErrorTypes(t *testing.T) {
+	mapper := ErrorAs(func(err *MockTypedError) Response {
+		return ErrorResponse{StatusCode: err.Code}
+	})
+	response, ok := mapper(fmt.Errorf("loading: %w", &MockTypedError{Code: 418}))
+	if !ok {
+		t.Fatal("Expected typed error to match")
+	}
+	if response.(ErrorResponse).StatusCode != 418 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 418, response.(ErrorResponse).StatusCode)
+	}
+	if _, ok := mapper(ErrMockNotFound); ok {
+		t.Error("Expected other error not to match")
+	}
+}
+
+func TestRouterMapErrorMapsHandlerErrors(t *testing.T) {
+	router := NewRouter().MapError(ErrorIs(ErrMockNotFound, MockNotFoundResponse))
+	writer := serveError(router, fmt.Errorf("loading: %w", ErrMockNotFound))
+	if writer.Code != 404 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 404, writer.Code)
+	}
+}
+
+func TestRouterMapErrorAppliesToLinkedRouters(t *testing.T) {
+	otherRouter := NewRouter()
+	otherRouter.Route(GET, MOCK_PATH, CreateReturningMockHandler(ErrMockNotFound))
+	router := NewRouter().MapError(ErrorIs(ErrMockNotFound, MockNotFoundResponse))
+	router.Link(MOCK_LINK, otherRouter)
+	writer := httptest.NewRecorder()
+	router.ServeHTTP(writer, CreateMockHTTPRequest(GET, MOCK_LINKED_PATH))
+	if writer.Code != 404 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 404, writer.Code)
+	}
+}
+
+func TestInnerRouterMappersTakePrecedence(t *testing.T) {
+	otherRouter := NewRouter().MapError(ErrorIs(ErrMockNotFound, func(error) Response {
+		return ErrorResponse{StatusCode: 410, Message: "gone"}
+	}))
+	otherRouter.Route(GET, MOCK_PATH, CreateReturningMockHandler(ErrMockNotFound))
+	router := NewRouter().MapError(ErrorIs(ErrMockNotFound, MockNotFoundResponse))
+	router.Link(MOCK_LINK, otherRouter)
+	writer := httptest.NewRecorder()
+	router.ServeHTTP(writer, CreateMockHTTPRequest(GET, MOCK_LINKED_PATH))
+	if writer.Code != 410 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 410, writer.Code)
+	}
+}
+
+func TestServerMappersApplyAfterRouterMappers(t *testing.T) {
+	server := NewServer("").MapError(ErrorIs(ErrMockNotFound, MockNotFoundResponse))
+	router := NewRouter()
+	router.Route(GET, MOCK_PATH, CreateReturningMockHandler(ErrMockNotFound))
+	writer := httptest.NewRecorder()
+	request := CreateMockHTTPRequest(GET, MOCK_PATH).WithContext(server.server.BaseContext(nil))
+	router.ServeHTTP(writer, request)
+	if writer.Code != 404 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 404, writer.Code)
+	}
+}
+
+func TestHTTPErrorsAreWrittenWithPublicMessage(t *testing.T) {
+	writer := serveError(NewRouter(), fmt.Errorf("saving: %w", MockHTTPError{}))
+	if writer.Code != 409 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 409, writer.Code)
+	}
+	if writer.Body.String() != "conflict" {
+		t.Errorf(EXPECTED_STRING_ERROR, "conflict", writer.Body.String())
+	}
+}
+
+func TestMappersTakePrecedenceOverResponseErrors(t *testing.T) {
+	router := NewRouter().MapError(ErrorAs(func(err *ParamError) Response {
+		return ErrorResponse{StatusCode: 404, Message: "not found"}
+	}))
+	writer := serveError(router, &ParamError{Name: "id", Err: ErrMissingParameter})
+	if writer.Code != 404 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 404, writer.Code)
+	}
+}
+
+func TestUnmappedErrorsAreInternalServerErrors(t *testing.T) {
+	writer := serveError(NewRouter().MapError(ErrorIs(ErrMockNotFound, MockNotFoundResponse)), fmt.Errorf("error"))
+	if writer.Code != 500 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 500, writer.Code)
+	}
+}
+
+func TestResponseForWithoutScopeOrSettings(t *testing.T) {
+	response := responseFor(nil, settingsFrom(context.Background()), MockHTTPError{})
+	writer := httptest.NewRecorder()
+	response.Write(writer)
+	if writer.Code != http.StatusConflict {
+		t.Errorf(EXPECTED_DIGIT_ERROR, http.StatusConflict, writer.Code)
+	}
+}
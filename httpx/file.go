@@ -0,0 +1,253 @@
+package httpx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileResponse serves the contents of a file, read from Path on disk, from Name in FS, or from Content.
+// Ranges and conditional requests are handled as by http.ServeContent, and the Content-Type is derived from
+// the extension of the file name, or sniffed from its first 512 bytes.
+// Files read from disk or from FS are given an ETag derived from their size and modification time,
+// or from their content if they have no modification time, as in an embed.FS.
+// Missing files and directories are written as 404 Not Found.
+type FileResponse struct {
+	Headers http.Header
+	// Path is the path of a file on disk.
+	Path string
+	// FS and Name identify a file in a file system, such as an embed.FS.
+	FS   fs.FS
+	Name string
+	// Content is served if neither Path nor FS is set. Name is then only used to determine the Content-Type.
+	Content io.ReadSeeker
+	// ModTime is the modification time of the file, used for Last-Modified and If-Modified-Since.
+	// It defaults to the modification time of files read from disk or from FS.
+	ModTime time.Time
+	// ETag, if set, replaces the ETag derived for files read from disk or from FS. It must be quoted.
+	ETag string
+	// Attachment makes clients download the file rather than display it, through the Content-Disposition header.
+	Attachment bool
+	// Filename is the file name suggested to clients in the Content-Disposition header.
+	// It defaults to the base name of the file when Attachment is set.
+	Filename string
+}
+
+// errRequestRequired is returned by responses that can only be written in answer to a request.
+var errRequestRequired = errors.New("response must be written in answer to a request")
+
+func (response FileResponse) Write(writer ResponseWriter) error {
+	request := requestOf(writer)
+	httpWriter, ok := writer.(http.ResponseWriter)
+	if request == nil || !ok {
+		return InternalServerError{errRequestRequired}.Write(writer)
+	}
+	file, err := response.open()
+	if errors.Is(err, fs.ErrNotExist) {
+		return NotFound{}.Write(writer)
+	}
+	if errors.Is(err, fs.ErrPermission) {
+		return Forbidden{}.Write(writer)
+	}
+	if err != nil {
+		return InternalServerError{err}.Write(writer)
+	}
+	if file.closer != nil {
+		defer file.closer.Close()
+	}
+	defaults := http.Header{}
+	if file.etag != "" {
+		defaults.Set("ETag", file.etag)
+	}
+	if disposition := response.disposition(file.name); disposition != "" {
+		defaults.Set("Content-Disposition", disposition)
+	}
+	setHeaders(writer.Header(), response.Headers, defaults)
+	http.ServeContent(httpWriter, request, file.name, file.modTime, file.content)
+	return nil
+}
+
+// openedFile is the content of a FileResponse, ready to be served.
+type openedFile struct {
+	content io.ReadSeeker
+	closer  io.Closer
+	name    string
+	modTime time.Time
+	etag    string
+}
+
+func (response FileResponse) open() (openedFile, error) {
+	var opened openedFile
+	var info fs.FileInfo
+	switch {
+	case response.Path != "":
+		file, err := os.Open(response.Path)
+		if err != nil {
+			return opened, err
+		}
+		opened.content, opened.closer, opened.name = file, file, filepath.Base(response.Path)
+		if info, err = file.Stat(); err != nil {
+			file.Close()
+			return opened, err
+		}
+	case response.FS != nil:
+		file, err := response.FS.Open(response.Name)
+		if err != nil {
+			return opened, err
+		}
+		opened.closer, opened.name = file, path.Base(response.Name)
+		if info, err = file.Stat(); err != nil {
+			file.Close()
+			return opened, err
+		}
+		if seeker, ok := file.(io.ReadSeeker); ok {
+			opened.content = seeker
+		} else if !info.IsDir() {
+			contents, err := io.ReadAll(file)
+			if err != nil {
+				file.Close()
+				return opened, err
+			}
+			opened.content = bytes.NewReader(contents)
+		}
+	case response.Content != nil:
+		opened.content, opened.name = response.Content, path.Base(response.Name)
+	default:
+		return opened, fs.ErrNotExist
+	}
+	if info != nil {
+		if info.IsDir() {
+			opened.closer.Close()
+			return opened, fs.ErrNotExist
+		}
+		opened.modTime = info.ModTime()
+		if !info.ModTime().IsZero() {
+			opened.etag = fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size())
+		} else if response.ETag == "" {
+			etag, err := contentETag(opened.content)
+			if err != nil {
+				opened.closer.Close()
+				return opened, err
+			}
+			opened.etag = etag
+		}
+	}
+	if !response.ModTime.IsZero() {
+		opened.modTime = response.ModTime
+	}
+	if response.ETag != "" {
+		opened.etag = response.ETag
+	}
+	return opened, nil
+}
+
+// contentETag returns an ETag derived from the SHA-256 hash of content, for files without a modification time,
+// such as those of an embed.FS. content is left at its start.
+func contentETag(content io.ReadSeeker) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`"%x"`, hash.Sum(nil)[:16]), nil
+}
+
+// disposition returns the Content-Disposition header for the file, or "" if none is needed.
+func (response FileResponse) disposition(name string) string {
+	filename := response.Filename
+	if response.Attachment && filename == "" {
+		filename = name
+	}
+	if filename == "" || filename == "." || filename == "/" {
+		if response.Attachment {
+			return "attachment"
+		}
+		return ""
+	}
+	dispositionType := "inline"
+	if response.Attachment {
+		dispositionType = "attachment"
+	}
+	return mime.FormatMediaType(dispositionType, map[string]string{"filename": filename})
+}
+
+// StaticOption configures how Router.Static serves files.
+type StaticOption func(*staticOptions)
+
+type staticOptions struct {
+	index    string
+	fallback string
+}
+
+// WithIndex sets the file served for requests to a directory, which is "index.html" by default.
+func WithIndex(name string) StaticOption {
+	return func(options *staticOptions) {
+		options.index = name
+	}
+}
+
+// WithSPAFallback serves the named file, such as "index.html", for requests to missing files without an
+// extension, so that a single page application can handle its own routes. Missing assets, such as
+// "/app.js", are still answered with 404 Not Found.
+func WithSPAFallback(name string) StaticOption {
+	return func(options *staticOptions) {
+		options.fallback = name
+	}
+}
+
+// Static serves the files in files below prefix, which must start and end with a "/", as FileResponses.
+// Requests to a directory are served its index file, and are redirected to the path with a trailing slash
+// if they lack one. Directories are not listed.
+func (router *Router) Static(prefix string, files fs.FS, options ...StaticOption) *Router {
+	validatePrefix(prefix)
+	settings := staticOptions{index: "index.html"}
+	for _, option := range options {
+		option(&settings)
+	}
+	handler := func(request Request) (Response, error) {
+		return serveStatic(request, files, settings), nil
+	}
+	router.handle(RouteInfo{Method: GET, Path: prefix + "{file...}", Handler: "Static"}, adapt(handler))
+	return router
+}
+
+func serveStatic(request Request, files fs.FS, options staticOptions) Response {
+	name := strings.TrimSuffix(request.PathParam("file"), "/")
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) {
+		return NotFound{}
+	}
+	info, err := fs.Stat(files, name)
+	if err == nil && info.IsDir() {
+		if name != "." && !strings.HasSuffix(request.URL.Path, "/") {
+			location := path.Base(request.URL.EscapedPath()) + "/"
+			if request.URL.RawQuery != "" {
+				location += "?" + request.URL.RawQuery
+			}
+			return MovedPermanently{location}
+		}
+		name = path.Join(name, options.index)
+		info, err = fs.Stat(files, name)
+	}
+	if err == nil && !info.IsDir() {
+		return FileResponse{FS: files, Name: name}
+	}
+	if options.fallback != "" && path.Ext(name) == "" {
+		return FileResponse{FS: files, Name: options.fallback}
+	}
+	return NotFound{}
+}
@@ -0,0 +1,278 @@
+package httpx
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var MOCK_MOD_TIME = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func CreateMockFS() fstest.MapFS {
+	return fstest.MapFS{
+		"hello.txt":          {Data: []byte("hello, world"), ModTime: MOCK_MOD_TIME},
+		"index.html":         {Data: []byte("<html>root</html>"), ModTime: MOCK_MOD_TIME},
+		"docs/index.html":    {Data: []byte("<html>docs</html>"), ModTime: MOCK_MOD_TIME},
+		"assets/app.js":      {Data: []byte("console.log(1)"), ModTime: MOCK_MOD_TIME},
+		"assets/empty/.keep": {Data: nil, ModTime: MOCK_MOD_TIME},
+		"noextension":        {Data: []byte("%PDF-1.4 fake"), ModTime: MOCK_MOD_TIME},
+	}
+}
+
+func serveFile(response FileResponse, headers map[string]string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, MOCK_PATH, nil)
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+	response.Write(&requestWriter{recorder, request})
+	return recorder
+}
+
+func TestFileResponseServesFileFromFS(t *testing.T) {
+	recorder := serveFile(FileResponse{FS: CreateMockFS(), Name: "hello.txt"}, nil)
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "hello, world" {
+		t.Errorf("Expected file contents, got %d %q", recorder.Code, recorder.Body.String())
+	}
+	if contentType := recorder.Header().Get(CONTENT_TYPE_HEADER_KEY); contentType != "text/plain; charset=utf-8" {
+		t.Errorf(EXPECTED_STRING_ERROR, "text/plain; charset=utf-8", contentType)
+	}
+	if recorder.Header().Get("ETag") == "" || recorder.Header().Get("Last-Modified") != MOCK_MOD_TIME.Format(http.TimeFormat) {
+		t.Errorf("Expected validators, got %v", recorder.Header())
+	}
+	if recorder.Header().Get("Accept-Ranges") != "bytes" {
+		t.Errorf(EXPECTED_STRING_ERROR, "bytes", recorder.Header().Get("Accept-Ranges"))
+	}
+}
+
+func TestFileResponseServesFileFromPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.csv")
+	os.WriteFile(path, []byte("a,b\n1,2\n"), 0o600)
+	recorder := serveFile(FileResponse{Path: path, Attachment: true}, nil)
+	if recorder.Body.String() != "a,b\n1,2\n" {
+		t.Errorf(EXPECTED_STRING_ERROR, "a,b\n1,2\n", recorder.Body.String())
+	}
+	if contentType := recorder.Header().Get(CONTENT_TYPE_HEADER_KEY); !strings.HasPrefix(contentType, "text/csv") {
+		t.Errorf(EXPECTED_STRING_ERROR, "text/csv", contentType)
+	}
+	if disposition := recorder.Header().Get("Content-Disposition"); disposition != "attachment; filename=report.csv" {
+		t.Errorf(EXPECTED_STRING_ERROR, "attachment; filename=report.csv", disposition)
+	}
+}
+
+func TestFileResponseServesReadSeeker(t *testing.T) {
+	response := FileResponse{Content: strings.NewReader("%PDF-1.4 content"), ModTime: MOCK_MOD_TIME, ETag: `"v1"`}
+	recorder := serveFile(response, nil)
+	if contentType := recorder.Header().Get(CONTENT_TYPE_HEADER_KEY); contentType != "application/pdf" {
+		t.Errorf(EXPECTED_STRING_ERROR, "application/pdf", contentType)
+	}
+	if recorder.Header().Get("ETag") != `"v1"` {
+		t.Errorf(EXPECTED_STRING_ERROR, `"v1"`, recorder.Header().Get("ETag"))
+	}
+}
+
+func TestFileResponseSniffsContentTypeWithoutExtension(t *testing.T) {
+	recorder := serveFile(FileResponse{FS: CreateMockFS(), Name: "noextension"}, nil)
+	if contentType := recorder.Header().Get(CONTENT_TYPE_HEADER_KEY); contentType != "application/pdf" {
+		t.Errorf(EXPECTED_STRING_ERROR, "application/pdf", contentType)
+	}
+}
+
+func TestFileResponseHeadersOverrideDefaults(t *testing.T) {
+	response := FileResponse{FS: CreateMockFS(), Name: "hello.txt", Headers: http.Header{"Content-Type": {"text/markdown"}}}
+	recorder := serveFile(response, nil)
+	if contentType := recorder.Header().Get(CONTENT_TYPE_HEADER_KEY); contentType != "text/markdown" {
+		t.Errorf(EXPECTED_STRING_ERROR, "text/markdown", contentType)
+	}
+}
+
+func TestFileResponseEncodesNonASCIIFilenames(t *testing.T) {
+	recorder := serveFile(FileResponse{FS: CreateMockFS(), Name: "hello.txt", Filename: "résumé.txt"}, nil)
+	disposition, params, err := mime.ParseMediaType(recorder.Header().Get("Content-Disposition"))
+	if err != nil || disposition != "inline" || params["filename"] != "résumé.txt" {
+		t.Errorf("Expected inline résumé.txt, got %q", recorder.Header().Get("Content-Disposition"))
+	}
+}
+
+func TestFileResponseServesByteRange(t *testing.T) {
+	recorder := serveFile(FileResponse{FS: CreateMockFS(), Name: "hello.txt"}, map[string]string{"Range": "bytes=7-"})
+	if recorder.Code != http.StatusPartialContent || recorder.Body.String() != "world" {
+		t.Errorf("Expected partial content, got %d %q", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Header().Get("Content-Range") != "bytes 7-11/12" {
+		t.Errorf(EXPECTED_STRING_ERROR, "bytes 7-11/12", recorder.Header().Get("Content-Range"))
+	}
+}
+
+func TestFileResponseServesMultipartRanges(t *testing.T) {
+	recorder := serveFile(FileResponse{FS: CreateMockFS(), Name: "hello.txt"}, map[string]string{"Range": "bytes=0-4,7-11"})
+	mediaType, params, _ := mime.ParseMediaType(recorder.Header().Get(CONTENT_TYPE_HEADER_KEY))
+	if recorder.Code != http.StatusPartialContent || mediaType != "multipart/byteranges" {
+		t.Fatalf("Expected multipart ranges, got %d %q", recorder.Code, mediaType)
+	}
+	reader := multipart.NewReader(recorder.Body, params["boundary"])
+	for _, expected := range []string{"hello", "world"} {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Fatalf("Failed to read part, got %v", err)
+		}
+		if body, _ := io.ReadAll(part); string(body) != expected {
+			t.Errorf(EXPECTED_STRING_ERROR, expected, body)
+		}
+	}
+}
+
+func TestFileResponseRejectsUnsatisfiableRange(t *testing.T) {
+	recorder := serveFile(FileResponse{FS: CreateMockFS(), Name: "hello.txt"}, map[string]string{"Range": "bytes=100-"})
+	if recorder.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf(EXPECTED_DIGIT_ERROR, http.StatusRequestedRangeNotSatisfiable, recorder.Code)
+	}
+}
+
+func TestFileResponseHonoursConditionalRequests(t *testing.T) {
+	etag := serveFile(FileResponse{FS: CreateMockFS(), Name: "hello.txt"}, nil).Header().Get("ETag")
+	tests := map[string]struct {
+		headers map[string]string
+		status  int
+	}{
+		"matching etag":         {map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		"other etag":            {map[string]string{"If-None-Match": `"other"`}, http.StatusOK},
+		"not modified since":    {map[string]string{"If-Modified-Since": MOCK_MOD_TIME.Format(http.TimeFormat)}, http.StatusNotModified},
+		"modified since":        {map[string]string{"If-Modified-Since": MOCK_MOD_TIME.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusOK},
+		"failed precondition":   {map[string]string{"If-Match": `"other"`}, http.StatusPreconditionFailed},
+		"stale range validator": {map[string]string{"Range": "bytes=0-4", "If-Range": `"other"`}, http.StatusOK},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			recorder := serveFile(FileResponse{FS: CreateMockFS(), Name: "hello.txt"}, test.headers)
+			if recorder.Code != test.status {
+				t.Errorf(EXPECTED_DIGIT_ERROR, test.status, recorder.Code)
+			}
+		})
+	}
+}
+
+func TestFileResponseWritesNotFound(t *testing.T) {
+	for _, response := range []FileResponse{
+		{FS: CreateMockFS(), Name: "missing.txt"},
+		{FS: CreateMockFS(), Name: "docs"},
+		{Path: filepath.Join(t.TempDir(), "missing.txt")},
+		{Path: t.TempDir()},
+		{},
+	} {
+		if recorder := serveFile(response, nil); recorder.Code != http.StatusNotFound {
+			t.Errorf(EXPECTED_DIGIT_ERROR, http.StatusNotFound, recorder.Code)
+		}
+	}
+}
+
+func TestFileResponseRequiresRequest(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	FileResponse{FS: CreateMockFS(), Name: "hello.txt"}.Write(recorder)
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf(EXPECTED_DIGIT_ERROR, http.StatusInternalServerError, recorder.Code)
+	}
+}
+
+func serveStaticRequest(router *Router, method string, path string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+	return recorder
+}
+
+func TestStaticServesFiles(t *testing.T) {
+	router := NewRouter().Static("/static/", CreateMockFS())
+	tests := map[string]struct {
+		status int
+		body   string
+	}{
+		"/static/hello.txt":     {http.StatusOK, "hello, world"},
+		"/static/assets/app.js": {http.StatusOK, "console.log(1)"},
+		"/static/":              {http.StatusOK, "<html>root</html>"},
+		"/static/docs/":         {http.StatusOK, "<html>docs</html>"},
+		"/static/missing.txt":   {http.StatusNotFound, "not found"},
+		"/static/assets/empty/": {http.StatusNotFound, "not found"},
+		"/static/missing":       {http.StatusNotFound, "not found"},
+	}
+	for path, test := range tests {
+		t.Run(path, func(t *testing.T) {
+			recorder := serveStaticRequest(router, http.MethodGet, path)
+			if recorder.Code != test.status || recorder.Body.String() != test.body {
+				t.Errorf("Expected %d %q, got %d %q", test.status, test.body, recorder.Code, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestStaticRedirectsDirectoriesToTrailingSlash(t *testing.T) {
+	router := NewRouter().Static("/static/", CreateMockFS())
+	recorder := serveStaticRequest(router, http.MethodGet, "/static/docs?page=2")
+	if recorder.Code != http.StatusMovedPermanently || recorder.Header().Get("Location") != "docs/?page=2" {
+		t.Errorf("Expected redirect to docs/?page=2, got %d %q", recorder.Code, recorder.Header().Get("Location"))
+	}
+	files := CreateMockFS()
+	files["why?/index.html"] = &fstest.MapFile{Data: []byte("<html>why</html>"), ModTime: MOCK_MOD_TIME}
+	recorder = serveStaticRequest(NewRouter().Static("/static/", files), http.MethodGet, "/static/why%3F")
+	if recorder.Code != http.StatusMovedPermanently || recorder.Header().Get("Location") != "why%3F/" {
+		t.Errorf("Expected redirect to why%%3F/, got %d %q", recorder.Code, recorder.Header().Get("Location"))
+	}
+}
+
+func TestStaticDerivesETagsFromContentWithoutModTime(t *testing.T) {
+	files := fstest.MapFS{"a.txt": {Data: []byte("abc")}, "b.txt": {Data: []byte("xyz")}}
+	router := NewRouter().Static("/static/", files)
+	first := serveStaticRequest(router, http.MethodGet, "/static/a.txt").Header().Get("ETag")
+	other := serveStaticRequest(router, http.MethodGet, "/static/b.txt").Header().Get("ETag")
+	if first == "" || first == other {
+		t.Errorf("Expected distinct ETags for files of the same size, got %s and %s", first, other)
+	}
+	files["a.txt"] = &fstest.MapFile{Data: []byte("abd")}
+	request := httptest.NewRequest(http.MethodGet, "/static/a.txt", nil)
+	request.Header.Set("If-None-Match", first)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "abd" {
+		t.Errorf("Expected the changed file to be served, got %d %q", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestStaticAnswersHeadRequests(t *testing.T) {
+	router := NewRouter().Static("/static/", CreateMockFS())
+	recorder := serveStaticRequest(router, http.MethodHead, "/static/hello.txt")
+	if recorder.Code != http.StatusOK || recorder.Body.Len() != 0 || recorder.Header().Get("Content-Length") != "12" {
+		t.Errorf("Expected headers only, got %d %q %v", recorder.Code, recorder.Body.String(), recorder.Header())
+	}
+}
+
+func TestStaticFallsBackToSPAIndex(t *testing.T) {
+	router := NewRouter().Static("/app/", CreateMockFS(), WithSPAFallback("index.html"))
+	if recorder := serveStaticRequest(router, http.MethodGet, "/app/users/42"); recorder.Body.String() != "<html>root</html>" {
+		t.Errorf(EXPECTED_STRING_ERROR, "<html>root</html>", recorder.Body.String())
+	}
+	if recorder := serveStaticRequest(router, http.MethodGet, "/app/missing.js"); recorder.Code != http.StatusNotFound {
+		t.Errorf(EXPECTED_DIGIT_ERROR, http.StatusNotFound, recorder.Code)
+	}
+}
+
+func TestStaticUsesConfiguredIndex(t *testing.T) {
+	files := fstest.MapFS{"docs/README.txt": {Data: []byte("readme")}}
+	router := NewRouter().Static("/", files, WithIndex("README.txt"))
+	if recorder := serveStaticRequest(router, http.MethodGet, "/docs/"); recorder.Body.String() != "readme" {
+		t.Errorf(EXPECTED_STRING_ERROR, "readme", recorder.Body.String())
+	}
+}
+
+func TestStaticWorksBehindLink(t *testing.T) {
+	router := NewRouter().Link("/v1/", NewRouter().Static("/static/", CreateMockFS()))
+	if recorder := serveStaticRequest(router, http.MethodGet, "/v1/static/hello.txt"); recorder.Body.String() != "hello, world" {
+		t.Errorf(EXPECTED_STRING_ERROR, "hello, world", recorder.Body.String())
+	}
+}
@@ -1,7 +1,6 @@
 package httpx
 
 import (
-	"fmt"
 	"net/http"
 )
 
@@ -9,18 +8,20 @@ import (
 type Handler = func(Request) (Response, error)
 
 // adapt adapts a handler to the http.HandlerFunc interface by ensuring the the return response is written to the http.ResponseWriter.
-// It recovers from panics and any errors returned by the handler.
-// Both cases result in an 500 response.
+// It recovers from panics, which are reported by recoverPanic and result in a 500 response.
+// Errors returned by the handler are converted to a response by responseFor.
 func adapt(handler Handler) http.Handler {
-	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+	return http.HandlerFunc(func(httpWriter http.ResponseWriter, request *http.Request) {
+		writer := &requestWriter{httpWriter, request}
 		defer func() {
-			if err := recover(); err != nil {
-				InternalServerError{fmt.Errorf("internal server error")}.Write(writer)
+			if recovered := recover(); recovered != nil {
+				recoverPanic(writer, request, recovered)
 			}
 		}()
 		response, err := handler(Request(*request))
 		if err != nil {
-			response = InternalServerError{fmt.Errorf("internal server error")}
+			ctx := request.Context()
+			response = responseFor(routerScopeFrom(ctx), settingsFrom(ctx), err)
 		}
 		response.Write(writer)
 	})
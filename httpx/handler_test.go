@@ -72,3 +72,30 @@ func TestAdaptedHandlerCatchesErrorsAndReturnsInternalServerError(t *testing.T)
 		t.Errorf("Expected status code 500, got %d", writer.Code)
 	}
 }
+
+func TestAdaptedHandlerWritesErrorsThatAreResponses(t *testing.T) {
+	adaptedHandler := adapt(func(r Request) (Response, error) {
+		_, err := r.PathInt("id")
+		return nil, err
+	})
+	writer := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.SetPathValue("id", "abc")
+	adaptedHandler.ServeHTTP(writer, request)
+	if writer.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code 400, got %d", writer.Code)
+	}
+}
+
+func TestAdaptedHandlerWritesWrappedErrorsThatAreResponses(t *testing.T) {
+	adaptedHandler := adapt(func(r Request) (Response, error) {
+		_, err := r.PathInt("id")
+		return nil, fmt.Errorf("loading user: %w", err)
+	})
+	writer := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	adaptedHandler.ServeHTTP(writer, request)
+	if writer.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code 400, got %d", writer.Code)
+	}
+}
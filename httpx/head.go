@@ -0,0 +1,81 @@
+package httpx
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// headless adapts a GET handler to also answer HEAD requests, discarding the body it writes.
+func headless(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != string(HEAD) {
+			handler.ServeHTTP(writer, request)
+			return
+		}
+		headWriter := &headWriter{ResponseWriter: writer}
+		handler.ServeHTTP(headWriter, request)
+		headWriter.sendHeader(true)
+	})
+}
+
+// answersHead reports whether any of the GET candidates answers HEAD requests, which WebSocket routes do not.
+func answersHead(candidates []candidate) bool {
+	return slices.ContainsFunc(candidates, func(candidate candidate) bool {
+		_, upgrades := candidate.handler.(webSocketRoute)
+		return !upgrades
+	})
+}
+
+// headWriter discards the body written in answer to a HEAD request.
+// The status line is held back until the handler returns or flushes,
+// so that the Content-Length of the discarded body can still be sent.
+type headWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	length      int
+	wroteHeader bool
+}
+
+func (writer *headWriter) WriteHeader(statusCode int) {
+	if writer.statusCode == 0 {
+		writer.statusCode = statusCode
+	}
+}
+
+func (writer *headWriter) Write(body []byte) (int, error) {
+	writer.length += len(body)
+	return len(body), nil
+}
+
+// FlushError sends the status line and flushes the underlying writer.
+//
+// see http.ResponseController.Flush for more details.
+func (writer *headWriter) FlushError() error {
+	writer.sendHeader(false)
+	return http.NewResponseController(writer.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying http.ResponseWriter.
+//
+// see http.ResponseController for more details.
+func (writer *headWriter) Unwrap() http.ResponseWriter {
+	return writer.ResponseWriter
+}
+
+// sendHeader writes the status line, if it has not been written yet.
+// Once the body is complete, its length is sent as the Content-Length unless the handler set one.
+func (writer *headWriter) sendHeader(complete bool) {
+	if writer.wroteHeader {
+		return
+	}
+	writer.wroteHeader = true
+	if writer.statusCode == 0 {
+		writer.statusCode = http.StatusOK
+	}
+	header := writer.Header()
+	if complete && writer.length > 0 && header.Get("Content-Length") == "" && header.Get("Transfer-Encoding") == "" {
+		header.Set("Content-Length", strconv.Itoa(writer.length))
+	}
+	writer.ResponseWriter.WriteHeader(writer.statusCode)
+}
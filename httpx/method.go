@@ -1,5 +1,7 @@
 package httpx
 
+import "strings"
+
 type Method string
 
 const (
@@ -13,3 +15,15 @@ const (
 	CONNECT Method = "CONNECT"
 	TRACE   Method = "TRACE"
 )
+
+// methods lists every Method, in the order in which they are reported in Allow headers.
+var methods = []Method{GET, POST, DELETE, PATCH, PUT, HEAD, OPTIONS, CONNECT, TRACE}
+
+// joinMethods formats methods as the value of an Allow header.
+func joinMethods(methods []Method) string {
+	names := make([]string, len(methods))
+	for i, method := range methods {
+		names[i] = string(method)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,185 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// MessagePackEncoder encodes values as application/msgpack, mapped as encoding/json would map them,
+// with map entries sorted by their encoded keys.
+// It is only available once added with Server.WithEncoder; fields tagged json:"-" are skipped,
+// but json.Marshaler implementations are not called.
+type MessagePackEncoder struct{}
+
+func (MessagePackEncoder) MediaType() string {
+	return "application/msgpack"
+}
+
+func (MessagePackEncoder) Encode(writer io.Writer, value any) error {
+	encoder := msgpackEncoder{}
+	if err := encoder.encode(reflect.ValueOf(value)); err != nil {
+		return err
+	}
+	_, err := writer.Write(encoder.buffer)
+	return err
+}
+
+type msgpackEncoder struct {
+	buffer []byte
+}
+
+// length writes the header of a string, bin, array or map value using the smallest format that fits.
+// fixed is the prefix of the fix format, or 0 if there is none, and fixedLimit is the exclusive bound of that format.
+func (encoder *msgpackEncoder) length(n int, fixed byte, fixedLimit int, prefix8 byte, prefix16 byte, prefix32 byte) {
+	switch {
+	case fixed != 0 && n < fixedLimit:
+		encoder.buffer = append(encoder.buffer, fixed|byte(n))
+	case prefix8 != 0 && n <= math.MaxUint8:
+		encoder.buffer = append(encoder.buffer, prefix8, byte(n))
+	case n <= math.MaxUint16:
+		encoder.buffer = binary.BigEndian.AppendUint16(append(encoder.buffer, prefix16), uint16(n))
+	default:
+		encoder.buffer = binary.BigEndian.AppendUint32(append(encoder.buffer, prefix32), uint32(n))
+	}
+}
+
+func (encoder *msgpackEncoder) str(text string) {
+	encoder.length(len(text), 0xa0, 32, 0xd9, 0xda, 0xdb)
+	encoder.buffer = append(encoder.buffer, text...)
+}
+
+func (encoder *msgpackEncoder) int(n int64) {
+	switch {
+	case n >= 0:
+		encoder.uint(uint64(n))
+	case n >= -32:
+		encoder.buffer = append(encoder.buffer, byte(n))
+	case n >= math.MinInt8:
+		encoder.buffer = append(encoder.buffer, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		encoder.buffer = binary.BigEndian.AppendUint16(append(encoder.buffer, 0xd1), uint16(n))
+	case n >= math.MinInt32:
+		encoder.buffer = binary.BigEndian.AppendUint32(append(encoder.buffer, 0xd2), uint32(n))
+	default:
+		encoder.buffer = binary.BigEndian.AppendUint64(append(encoder.buffer, 0xd3), uint64(n))
+	}
+}
+
+func (encoder *msgpackEncoder) uint(n uint64) {
+	switch {
+	case n < 128:
+		encoder.buffer = append(encoder.buffer, byte(n))
+	case n <= math.MaxUint8:
+		encoder.buffer = append(encoder.buffer, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		encoder.buffer = binary.BigEndian.AppendUint16(append(encoder.buffer, 0xcd), uint16(n))
+	case n <= math.MaxUint32:
+		encoder.buffer = binary.BigEndian.AppendUint32(append(encoder.buffer, 0xce), uint32(n))
+	default:
+		encoder.buffer = binary.BigEndian.AppendUint64(append(encoder.buffer, 0xcf), n)
+	}
+}
+
+func (encoder *msgpackEncoder) encode(value reflect.Value) error {
+	if !value.IsValid() {
+		encoder.buffer = append(encoder.buffer, 0xc0)
+		return nil
+	}
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		if value.IsNil() {
+			encoder.buffer = append(encoder.buffer, 0xc0)
+			return nil
+		}
+	}
+	if value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		return encoder.encode(value.Elem())
+	}
+	if value.Type().Implements(textMarshalerType) {
+		text, err := value.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+		encoder.str(string(text))
+		return nil
+	}
+	switch value.Kind() {
+	case reflect.Bool:
+		if value.Bool() {
+			encoder.buffer = append(encoder.buffer, 0xc3)
+		} else {
+			encoder.buffer = append(encoder.buffer, 0xc2)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		encoder.int(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		encoder.uint(value.Uint())
+	case reflect.Float32:
+		encoder.buffer = binary.BigEndian.AppendUint32(append(encoder.buffer, 0xca), math.Float32bits(float32(value.Float())))
+	case reflect.Float64:
+		encoder.buffer = binary.BigEndian.AppendUint64(append(encoder.buffer, 0xcb), math.Float64bits(value.Float()))
+	case reflect.String:
+		encoder.str(value.String())
+	case reflect.Slice, reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			encoder.length(value.Len(), 0, 0, 0xc4, 0xc5, 0xc6)
+			for i := 0; i < value.Len(); i++ {
+				encoder.buffer = append(encoder.buffer, byte(value.Index(i).Uint()))
+			}
+			return nil
+		}
+		encoder.length(value.Len(), 0x90, 16, 0, 0xdc, 0xdd)
+		for i := 0; i < value.Len(); i++ {
+			if err := encoder.encode(value.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		return encoder.encodeMap(value)
+	case reflect.Struct:
+		fields, values := nonEmptyFields(value)
+		encoder.length(len(fields), 0x80, 16, 0, 0xde, 0xdf)
+		for i, field := range fields {
+			encoder.str(field.name)
+			if err := encoder.encode(values[i]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("msgpack: unsupported type %s", value.Type())
+	}
+	return nil
+}
+
+func (encoder *msgpackEncoder) encodeMap(value reflect.Value) error {
+	type entry struct {
+		key   []byte
+		value reflect.Value
+	}
+	entries := make([]entry, 0, value.Len())
+	iterator := value.MapRange()
+	for iterator.Next() {
+		keyEncoder := msgpackEncoder{}
+		if err := keyEncoder.encode(iterator.Key()); err != nil {
+			return err
+		}
+		entries = append(entries, entry{keyEncoder.buffer, iterator.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].key, entries[j].key) < 0
+	})
+	encoder.length(len(entries), 0x80, 16, 0, 0xde, 0xdf)
+	for _, entry := range entries {
+		encoder.buffer = append(encoder.buffer, entry.key...)
+		if err := encoder.encode(entry.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
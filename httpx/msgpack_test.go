@@ -0,0 +1,88 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+	"strings"
+	"testing"
+)
+
+func encodeMessagePack(t *testing.T, value any) string {
+	buffer := &bytes.Buffer{}
+	if err := (MessagePackEncoder{}).Encode(buffer, value); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return hex.EncodeToString(buffer.Bytes())
+}
+
+func TestMessagePackEncoderEncodesScalars(t *testing.T) {
+	cases := []struct {
+		value    any
+		expected string
+	}{
+		{nil, "c0"},
+		{false, "c2"},
+		{true, "c3"},
+		{0, "00"},
+		{127, "7f"},
+		{128, "cc80"},
+		{256, "cd0100"},
+		{65536, "ce00010000"},
+		{uint64(math.MaxUint64), "cfffffffffffffffff"},
+		{-1, "ff"},
+		{-32, "e0"},
+		{-33, "d0df"},
+		{-129, "d1ff7f"},
+		{-32769, "d2ffff7fff"},
+		{int64(math.MinInt64), "d38000000000000000"},
+		{float32(1.5), "ca3fc00000"},
+		{1.5, "cb3ff8000000000000"},
+		{"", "a0"},
+		{"abc", "a3616263"},
+		{strings.Repeat("a", 32), "d920" + strings.Repeat("61", 32)},
+		{strings.Repeat("a", 256), "da0100" + strings.Repeat("61", 256)},
+		{[]byte{1, 2}, "c4020102"},
+	}
+	for _, c := range cases {
+		if actual := encodeMessagePack(t, c.value); actual != c.expected {
+			t.Errorf("Encoding %v: "+EXPECTED_STRING_ERROR, c.value, c.expected, actual)
+		}
+	}
+}
+
+func TestMessagePackEncoderEncodesCollections(t *testing.T) {
+	cases := []struct {
+		value    any
+		expected string
+	}{
+		{[]int{1, 2}, "920102"},
+		{make([]int, 16), "dc0010" + strings.Repeat("00", 16)},
+		{map[string]int{"b": 2, "a": 1}, "82a16101a16202"},
+		{[]string(nil), "c0"},
+		{struct {
+			A int    `json:"a"`
+			B string `json:"b,omitempty"`
+			C *int   `json:"c"`
+		}{A: 1}, "82a16101a163c0"},
+	}
+	for _, c := range cases {
+		if actual := encodeMessagePack(t, c.value); actual != c.expected {
+			t.Errorf("Encoding %v: "+EXPECTED_STRING_ERROR, c.value, c.expected, actual)
+		}
+	}
+}
+
+func TestMessagePackEncoderEncodesTextMarshalersAsStrings(t *testing.T) {
+	uuid, _ := ParseUUID(MOCK_UUID)
+	expected := "d924" + hex.EncodeToString([]byte(MOCK_UUID))
+	if actual := encodeMessagePack(t, &uuid); actual != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, actual)
+	}
+}
+
+func TestMessagePackEncoderRejectsUnsupportedTypes(t *testing.T) {
+	if err := (MessagePackEncoder{}).Encode(&bytes.Buffer{}, []any{make(chan int)}); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
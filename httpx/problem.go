@@ -0,0 +1,81 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const problemContentType = "application/problem+json"
+
+// ProblemResponse is an RFC 9457 problem details response, written as application/problem+json.
+// Type defaults to "about:blank", in which case Title defaults to the status text of Status; other empty members
+// are omitted. Extensions are written as additional members that cannot replace the standard members.
+type ProblemResponse struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]any
+	Headers    http.Header
+}
+
+func (response ProblemResponse) MarshalJSON() ([]byte, error) {
+	members := make(map[string]any, len(response.Extensions)+5)
+	for key, value := range response.Extensions {
+		members[key] = value
+	}
+	members["type"] = response.Type
+	if response.Type == "" {
+		members["type"] = "about:blank"
+	}
+	switch {
+	case response.Title != "":
+		members["title"] = response.Title
+	case members["type"] == "about:blank":
+		members["title"] = http.StatusText(response.Status)
+	default:
+		delete(members, "title")
+	}
+	members["status"] = response.Status
+	if response.Detail != "" {
+		members["detail"] = response.Detail
+	} else {
+		delete(members, "detail")
+	}
+	if response.Instance != "" {
+		members["instance"] = response.Instance
+	} else {
+		delete(members, "instance")
+	}
+	return json.Marshal(members)
+}
+
+func (response ProblemResponse) Write(writer ResponseWriter) error {
+	body, err := json.Marshal(response)
+	if err != nil {
+		return InternalServerError{err}.Write(writer)
+	}
+	writeHeader(writer, response.Status, response.Headers, http.Header{"Content-Type": {problemContentType}})
+	_, err = writer.Write(body)
+	return err
+}
+
+// problemFor converts an ErrorResponse to the equivalent ProblemResponse.
+// The error, or failing that a message that says more than the status text, becomes the detail.
+// Field level errors are listed in an "errors" extension member.
+func problemFor(response ErrorResponse) ProblemResponse {
+	problem := ProblemResponse{Status: response.StatusCode, Headers: response.Headers}
+	if response.Error != nil {
+		problem.Detail = response.Error.Error()
+	} else if !strings.EqualFold(response.Message, http.StatusText(response.StatusCode)) {
+		problem.Detail = response.Message
+	}
+	var bindErr *BindError
+	if errors.As(response.Error, &bindErr) {
+		problem.Extensions = map[string]any{"errors": bindErr.Fields}
+	}
+	return problem
+}
@@ -0,0 +1,139 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const CONTENT_TYPE_PROBLEM = "application/problem+json"
+
+func CreateProblemDetailsWriter() (*requestWriter, *httptest.ResponseRecorder) {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx := withSettings(context.Background(), &settings{problemDetails: true})
+	return &requestWriter{recorder, request.WithContext(ctx)}, recorder
+}
+
+func decodeProblem(t *testing.T, recorder *httptest.ResponseRecorder) map[string]any {
+	var members map[string]any
+	if err := json.Unmarshal(recorder.Body.Bytes(), &members); err != nil {
+		t.Fatalf("Expected JSON body, got %s", recorder.Body.String())
+	}
+	return members
+}
+
+func TestProblemResponseWritesMembers(t *testing.T) {
+	response := ProblemResponse{
+		Type:       "https://example.com/probs/out-of-credit",
+		Title:      "You do not have enough credit.",
+		Status:     403,
+		Detail:     "Your current balance is 30, but that costs 50.",
+		Instance:   "/account/12345/msgs/abc",
+		Extensions: map[string]any{"balance": 30, "status": 200},
+	}
+	writer := httptest.NewRecorder()
+	response.Write(writer)
+	if writer.Code != 403 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 403, writer.Code)
+	}
+	if writer.Header().Get(CONTENT_TYPE_HEADER_KEY) != CONTENT_TYPE_PROBLEM {
+		t.Errorf(EXPECTED_STRING_ERROR, CONTENT_TYPE_PROBLEM, writer.Header().Get(CONTENT_TYPE_HEADER_KEY))
+	}
+	expected := `{"balance":30,"detail":"Your current balance is 30, but that costs 50.","instance":"/account/12345/msgs/abc",` +
+		`"status":403,"title":"You do not have enough credit.","type":"https://example.com/probs/out-of-credit"}`
+	if writer.Body.String() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, writer.Body.String())
+	}
+}
+
+func TestProblemResponseDefaultsTypeAndTitle(t *testing.T) {
+	writer := httptest.NewRecorder()
+	ProblemResponse{Status: 404}.Write(writer)
+	expected := `{"status":404,"title":"Not Found","type":"about:blank"}`
+	if writer.Body.String() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, writer.Body.String())
+	}
+}
+
+func TestProblemResponseDoesNotDefaultTitleForCustomType(t *testing.T) {
+	writer := httptest.NewRecorder()
+	ProblemResponse{Type: "https://example.com/probs/x", Status: 404, Extensions: map[string]any{"title": "x"}}.Write(writer)
+	expected := `{"status":404,"type":"https://example.com/probs/x"}`
+	if writer.Body.String() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, writer.Body.String())
+	}
+}
+
+func TestProblemResponseRaisesInternalServerErrorOnEncodingError(t *testing.T) {
+	writer := httptest.NewRecorder()
+	ProblemResponse{Status: 400, Extensions: map[string]any{"bad": make(chan int)}}.Write(writer)
+	if writer.Code != 500 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 500, writer.Code)
+	}
+}
+
+func TestProblemDetailsRenderInternalServerError(t *testing.T) {
+	writer, recorder := CreateProblemDetailsWriter()
+	InternalServerError{fmt.Errorf("error")}.Write(writer)
+	members := decodeProblem(t, recorder)
+	if recorder.Header().Get(CONTENT_TYPE_HEADER_KEY) != CONTENT_TYPE_PROBLEM {
+		t.Errorf(EXPECTED_STRING_ERROR, CONTENT_TYPE_PROBLEM, recorder.Header().Get(CONTENT_TYPE_HEADER_KEY))
+	}
+	if members["status"] != 500.0 || members["title"] != "Internal Server Error" || members["detail"] != "error" {
+		t.Errorf("Unexpected problem %v", members)
+	}
+}
+
+func TestProblemDetailsRenderBadRequestWithFieldErrors(t *testing.T) {
+	writer, recorder := CreateProblemDetailsWriter()
+	(&BindError{Fields: []FieldError{{Field: "limit", Location: "query", Message: "invalid syntax"}}}).Write(writer)
+	members := decodeProblem(t, recorder)
+	if members["status"] != 400.0 || members["detail"] != "query limit: invalid syntax" {
+		t.Errorf("Unexpected problem %v", members)
+	}
+	if errors, ok := members["errors"].([]any); !ok || len(errors) != 1 {
+		t.Errorf("Expected errors member, got %v", members["errors"])
+	}
+}
+
+func TestProblemDetailsRenderServiceUnavailableWithoutDetail(t *testing.T) {
+	writer, recorder := CreateProblemDetailsWriter()
+	ServiceUnavailable{}.Write(writer)
+	members := decodeProblem(t, recorder)
+	if _, ok := members["detail"]; ok || members["status"] != 503.0 {
+		t.Errorf("Unexpected problem %v", members)
+	}
+}
+
+func TestProblemDetailsUseCustomMessageAsDetail(t *testing.T) {
+	writer, recorder := CreateProblemDetailsWriter()
+	ErrorResponse{StatusCode: 503, Message: "down for maintenance"}.Write(writer)
+	members := decodeProblem(t, recorder)
+	if members["detail"] != "down for maintenance" {
+		t.Errorf("Unexpected problem %v", members)
+	}
+}
+
+func TestProblemDetailsRenderUnprocessableEntity(t *testing.T) {
+	writer, recorder := CreateProblemDetailsWriter()
+	UnprocessableEntity{Fields: []FieldError{{Field: "name", Rule: "required", Message: "is required"}}}.Write(writer)
+	members := decodeProblem(t, recorder)
+	if members["status"] != 422.0 || members["errors"] == nil {
+		t.Errorf("Unexpected problem %v", members)
+	}
+}
+
+func TestAdaptedHandlerRendersProblemDetailsFromRequestContext(t *testing.T) {
+	handler, _ := CreateErrorMockHandler()
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx := withSettings(context.Background(), &settings{problemDetails: true})
+	adapt(handler).ServeHTTP(recorder, request.WithContext(ctx))
+	if recorder.Header().Get(CONTENT_TYPE_HEADER_KEY) != CONTENT_TYPE_PROBLEM {
+		t.Errorf(EXPECTED_STRING_ERROR, CONTENT_TYPE_PROBLEM, recorder.Header().Get(CONTENT_TYPE_HEADER_KEY))
+	}
+}
@@ -0,0 +1,274 @@
+package httpx
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// RadixMux is a Multiplexer that matches requests against a radix tree of http.ServeMux patterns without a host,
+// such as "GET /users/{id}/{$}". Literal segments take precedence over {name} wildcards, which take precedence
+// over trailing wildcards, and patterns with a method over those without. As with http.ServeMux, paths are matched
+// in their escaped form, and finding the pattern that matches a request does not allocate.
+type RadixMux struct {
+	root radixNode
+}
+
+// maxStackParams is the number of wildcard values that are matched without allocating.
+const maxStackParams = 8
+
+// radixNode is a node of the tree. Static nodes match their prefix, and their children continue the path:
+// static children by their first byte, then the wildcard child for a single segment, then the remainder child
+// for the rest of the path.
+type radixNode struct {
+	prefix    string
+	indices   string
+	static    []*radixNode
+	wildcard  *radixNode
+	remainder *radixNode
+	// routes are the patterns that end at the node.
+	routes []*radixRoute
+}
+
+// radixRoute is a pattern registered with a RadixMux.
+type radixRoute struct {
+	method  string
+	pattern string
+	// names are the names of the wildcards of the pattern, in order. Subtree patterns have a final unnamed wildcard.
+	names   []string
+	handler http.Handler
+}
+
+// radixToken is a part of a parsed pattern: a static string, or a wildcard with the given name.
+type radixToken struct {
+	static    string
+	wildcard  bool
+	remainder bool
+	name      string
+}
+
+// NewRadixMux creates an empty RadixMux. Use Router.WithMultiplexer to route the requests of a Router with it.
+func NewRadixMux() *RadixMux {
+	return &RadixMux{}
+}
+
+// Handle registers handler for pattern. It panics if pattern is invalid, has a host, or has the same method
+// and path as a registered pattern, up to the names of its wildcards.
+func (mux *RadixMux) Handle(pattern string, handler http.Handler) {
+	method, path, _ := strings.Cut(pattern, " ")
+	if !strings.Contains(pattern, " ") {
+		method, path = "", pattern
+	}
+	if !strings.HasPrefix(path, "/") {
+		panic(fmt.Sprintf("radix: pattern %q must have a path starting with a / and no host", pattern))
+	}
+	tokens, names := parseRadixPattern(pattern, path)
+	node := &mux.root
+	for _, token := range tokens {
+		switch {
+		case token.remainder:
+			if node.remainder == nil {
+				node.remainder = &radixNode{}
+			}
+			node = node.remainder
+		case token.wildcard:
+			if node.wildcard == nil {
+				node.wildcard = &radixNode{}
+			}
+			node = node.wildcard
+		default:
+			node = node.insertStatic(token.static)
+		}
+	}
+	for _, route := range node.routes {
+		if route.method == method {
+			panic(fmt.Sprintf("radix: pattern %q conflicts with pattern %q", pattern, route.pattern))
+		}
+	}
+	node.routes = append(node.routes, &radixRoute{method: method, pattern: pattern, names: names, handler: handler})
+}
+
+// parseRadixPattern returns the tokens of the path of pattern, and the names of its wildcards.
+func parseRadixPattern(pattern, path string) ([]radixToken, []string) {
+	var tokens []radixToken
+	var names []string
+	segments := strings.Split(path[1:], "/")
+	static := "/"
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		switch {
+		case segment == "{$}" && last:
+			return append(tokens, radixToken{static: static}), names
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "...}") && last:
+			name := segment[1 : len(segment)-4]
+			return append(tokens, radixToken{static: static}, radixToken{remainder: true, name: name}), append(names, name)
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+			name := segment[1 : len(segment)-1]
+			if name == "" || strings.ContainsAny(name, "{}:.$") {
+				panic(fmt.Sprintf("radix: pattern %q has an invalid wildcard %s", pattern, segment))
+			}
+			tokens = append(tokens, radixToken{static: static}, radixToken{wildcard: true, name: name})
+			names = append(names, name)
+			static = ""
+		case strings.ContainsAny(segment, "{}"):
+			panic(fmt.Sprintf("radix: pattern %q has an invalid segment %s", pattern, segment))
+		default:
+			static += segment
+		}
+		if !last {
+			static += "/"
+		}
+	}
+	if strings.HasSuffix(path, "/") {
+		return append(tokens, radixToken{static: static}, radixToken{remainder: true}), append(names, "")
+	}
+	return append(tokens, radixToken{static: static}), names
+}
+
+// insertStatic returns the node below node that matches static, splitting nodes that share a prefix with it.
+func (node *radixNode) insertStatic(static string) *radixNode {
+	for static != "" {
+		i := strings.IndexByte(node.indices, static[0])
+		if i < 0 {
+			child := &radixNode{prefix: static}
+			node.indices += static[:1]
+			node.static = append(node.static, child)
+			return child
+		}
+		child := node.static[i]
+		common := 0
+		for common < len(child.prefix) && common < len(static) && child.prefix[common] == static[common] {
+			common++
+		}
+		if common < len(child.prefix) {
+			split := *child
+			split.prefix = child.prefix[common:]
+			*child = radixNode{prefix: child.prefix[:common], indices: split.prefix[:1], static: []*radixNode{&split}}
+		}
+		static = static[common:]
+		node = child
+	}
+	return node
+}
+
+// lookup returns the route of the node or of the nodes below it that matches the method and the rest of the path,
+// with the values of its wildcards appended to values.
+func (node *radixNode) lookup(method, path string, values []string) (*radixRoute, []string) {
+	if path == "" {
+		if route := node.route(method); route != nil {
+			return route, values
+		}
+	} else if i := strings.IndexByte(node.indices, path[0]); i >= 0 {
+		child := node.static[i]
+		if strings.HasPrefix(path, child.prefix) {
+			if route, matched := child.lookup(method, path[len(child.prefix):], values); route != nil {
+				return route, matched
+			}
+		}
+	}
+	if node.wildcard != nil {
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		if end > 0 {
+			if route, matched := node.wildcard.lookup(method, path[end:], append(values, path[:end])); route != nil {
+				return route, matched
+			}
+		}
+	}
+	if node.remainder != nil {
+		if route := node.remainder.route(method); route != nil {
+			return route, append(values, path)
+		}
+	}
+	return nil, values
+}
+
+// route returns the route ending at the node that matches method, which is the route for the method, the GET
+// route for HEAD requests, or the route without a method.
+func (node *radixNode) route(method string) *radixRoute {
+	var fallback *radixRoute
+	for _, route := range node.routes {
+		switch {
+		case route.method == method:
+			return route
+		case method == http.MethodHead && route.method == http.MethodGet:
+			fallback = route
+		case route.method == "" && fallback == nil:
+			fallback = route
+		}
+	}
+	return fallback
+}
+
+// Handler returns the handler for the pattern that matches request, and the pattern, without allocating.
+// If the path of request lacks the trailing slash of a pattern, it returns a handler that redirects to the path
+// with the slash, and that pattern. The pattern is "" if no pattern matches.
+func (mux *RadixMux) Handler(request *http.Request) (http.Handler, string) {
+	var buffer [maxStackParams]string
+	escaped := request.URL.EscapedPath()
+	path := unescapeSegments(escaped)
+	if route, _ := mux.root.lookup(request.Method, path, buffer[:0]); route != nil {
+		return route.handler, route.pattern
+	}
+	if !strings.HasSuffix(path, "/") {
+		if route, _ := mux.root.lookup(request.Method, path+"/", buffer[:0]); route != nil {
+			location := escaped + "/"
+			if request.URL.RawQuery != "" {
+				location += "?" + request.URL.RawQuery
+			}
+			return http.RedirectHandler(location, http.StatusMovedPermanently), route.pattern
+		}
+	}
+	return http.NotFoundHandler(), ""
+}
+
+func (mux *RadixMux) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	handler, _ := mux.match(request)
+	handler.ServeHTTP(writer, request)
+}
+
+// match returns the handler and pattern that Handler returns for request, after setting the values of the
+// wildcards of the pattern on request.
+func (mux *RadixMux) match(request *http.Request) (http.Handler, string) {
+	var buffer [maxStackParams]string
+	route, values := mux.root.lookup(request.Method, unescapeSegments(request.URL.EscapedPath()), buffer[:0])
+	if route == nil {
+		return mux.Handler(request)
+	}
+	for i, name := range route.names {
+		if name == "" {
+			continue
+		}
+		value := values[i]
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			value = unescaped
+		}
+		request.SetPathValue(name, value)
+	}
+	return route.handler, route.pattern
+}
+
+// unescapeSegments decodes the escapes of an escaped path, other than those of "/" and "%", so that its segments
+// can be compared with the literals of patterns while an encoded "/" stays part of its segment. It only allocates
+// if the path has escapes.
+func unescapeSegments(path string) string {
+	if !strings.Contains(path, "%") {
+		return path
+	}
+	var builder strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '%' && i+2 < len(path) {
+			if decoded, err := strconv.ParseUint(path[i+1:i+3], 16, 8); err == nil && decoded != '/' && decoded != '%' {
+				builder.WriteByte(byte(decoded))
+				i += 2
+				continue
+			}
+		}
+		builder.WriteByte(path[i])
+	}
+	return builder.String()
+}
@@ -0,0 +1,254 @@
+package httpx
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func CreateRecordingHandler(name string, served *string) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		*served = name + " " + request.PathValue("id") + request.PathValue("path")
+	})
+}
+
+func TestRadixMuxPrefersStaticSegments(t *testing.T) {
+	var served string
+	mux := NewRadixMux()
+	mux.Handle("GET /users/{id}/{$}", CreateRecordingHandler("user", &served))
+	mux.Handle("GET /users/new/{$}", CreateRecordingHandler("new", &served))
+	mux.Handle("GET /users/{id}/posts/{$}", CreateRecordingHandler("posts", &served))
+	mux.Handle("GET /users/newest/posts/{$}", CreateRecordingHandler("newest", &served))
+	for path, expected := range map[string]string{
+		"/users/new/":          "new ",
+		"/users/42/":           "user 42",
+		"/users/new/posts/":    "posts new",
+		"/users/newest/posts/": "newest ",
+		"/users/newer/":        "user newer",
+	} {
+		served = ""
+		mux.ServeHTTP(httptest.NewRecorder(), CreateMockHTTPRequest(GET, path))
+		if served != expected {
+			t.Errorf("Expected %s to be served by %q, got %q", path, expected, served)
+		}
+	}
+}
+
+func TestRadixMuxMatchesRemaindersAndSubtrees(t *testing.T) {
+	var served string
+	mux := NewRadixMux()
+	mux.Handle("GET /files/{path...}", CreateRecordingHandler("files", &served))
+	mux.Handle("/link/", CreateRecordingHandler("link", &served))
+	for path, expected := range map[string]string{"/files/docs/a.md": "files docs/a.md", "/files/": "files ", "/link/x/y": "link "} {
+		served = ""
+		mux.ServeHTTP(httptest.NewRecorder(), CreateMockHTTPRequest(POST, path))
+		if path != "/link/x/y" {
+			mux.ServeHTTP(httptest.NewRecorder(), CreateMockHTTPRequest(HEAD, path))
+		}
+		if served != expected {
+			t.Errorf("Expected %s to be served by %q, got %q", path, expected, served)
+		}
+	}
+}
+
+func TestRadixMuxMatchesMethods(t *testing.T) {
+	mux := NewRadixMux()
+	handler := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	mux.Handle("GET /users/{$}", handler)
+	mux.Handle("HEAD /users/{$}", handler)
+	mux.Handle("/users/", handler)
+	cases := map[Method]string{GET: "GET /users/{$}", HEAD: "HEAD /users/{$}", POST: "/users/"}
+	for method, expected := range cases {
+		if _, pattern := mux.Handler(CreateMockHTTPRequest(method, "/users/")); pattern != expected {
+			t.Errorf(EXPECTED_STRING_ERROR, expected, pattern)
+		}
+	}
+	if _, pattern := mux.Handler(CreateMockHTTPRequest(GET, "/users")); pattern != "GET /users/{$}" {
+		t.Errorf("Expected the redirect to report GET /users/{$}, got %q", pattern)
+	}
+	if _, pattern := mux.Handler(CreateMockHTTPRequest(GET, "/posts/")); pattern != "" {
+		t.Errorf("Expected no pattern, got %q", pattern)
+	}
+}
+
+func TestRadixMuxPanicsOnInvalidPatterns(t *testing.T) {
+	handler := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	for _, pattern := range []string{"GET example.com/users/", "GET /users/{id:int}/", "GET /{}/"} {
+		t.Run(pattern, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error(PANIC_EXPECTED_ERROR)
+				}
+			}()
+			NewRadixMux().Handle(pattern, handler)
+		})
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error(PANIC_EXPECTED_ERROR)
+		}
+	}()
+	mux := NewRadixMux()
+	mux.Handle("GET /users/{id}/{$}", handler)
+	mux.Handle("GET /users/{name}/{$}", handler)
+}
+
+func TestRadixMuxMatchesWithoutAllocating(t *testing.T) {
+	mux := NewRadixMux()
+	mux.Handle("GET /users/{id}/posts/{post}/{$}", http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	request := CreateMockHTTPRequest(GET, "/users/42/posts/7/")
+	if allocations := testing.AllocsPerRun(100, func() { mux.Handler(request) }); allocations != 0 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 0, int(allocations))
+	}
+}
+
+func TestMergedRoutersWithRadixMuxDoNotAllocate(t *testing.T) {
+	handler := func(Request) (Response, error) { return NoContent{}, nil }
+	flat := NewRouter().WithMultiplexer(NewRadixMux()).Route(GET, "/users/{id}/", handler)
+	merged := NewRouter().WithMultiplexer(NewRadixMux()).
+		Merge(NewRouter().WithMultiplexer(NewRadixMux()).
+			Merge(NewRouter().WithMultiplexer(NewRadixMux()).Route(GET, "/users/{id}/", handler)))
+	serve := func(router *Router) func() {
+		request := CreateMockHTTPRequest(GET, "/users/42/")
+		writer := httptest.NewRecorder()
+		return func() { router.ServeHTTP(writer, request) }
+	}
+	routed := testing.AllocsPerRun(100, serve(flat))
+	if allocations := testing.AllocsPerRun(100, serve(merged)); allocations != routed {
+		t.Errorf("Expected merged routers to allocate %d times like a single router, got %d", int(routed), int(allocations))
+	}
+}
+
+func TestRouterWithRadixMux(t *testing.T) {
+	var values []string
+	inner := NewRouter().Route(GET, MOCK_PATH, recordParam("id", &values))
+	router := NewRouter().
+		Route(GET, "/users/{id:int}/", recordParam("id", &values)).
+		Route(DELETE, "/users/{id}/", recordParam("id", &values)).
+		Link(MOCK_LINK, inner).
+		WithMultiplexer(NewRadixMux())
+	router.Route(GET, "/teams/{id}/", recordParam("id", &values)).TrailingSlash(RedirectSlash)
+
+	cases := []struct {
+		method       Method
+		path         string
+		code         int
+		allow, value string
+	}{
+		{GET, "/users/42/", 204, "", "id=42"},
+		{HEAD, "/teams/7/", 204, "", "id=7"},
+		{GET, "/users/jane/", 404, "", ""},
+		{POST, "/users/42/", 405, "GET, DELETE, HEAD, OPTIONS", ""},
+		{OPTIONS, "/users/42/", 204, "GET, DELETE, HEAD, OPTIONS", ""},
+		{GET, "/users/42", 301, "", ""},
+		{GET, MOCK_LINKED_PATH, 204, "", "id="},
+		{GET, "/missing/", 404, "", ""},
+	}
+	for _, c := range cases {
+		values = nil
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, CreateMockHTTPRequest(c.method, c.path))
+		value := ""
+		if len(values) > 0 {
+			value = values[0]
+		}
+		if recorder.Code != c.code || recorder.Header().Get("Allow") != c.allow || value != c.value {
+			t.Errorf("Expected %s %s to give %d %q %q, got %d %q %q", c.method, c.path, c.code, c.allow, c.value, recorder.Code, recorder.Header().Get("Allow"), value)
+		}
+	}
+}
+
+func TestRadixMuxMatchesEscapedPathsLikeServeMux(t *testing.T) {
+	handler := func(request Request) (Response, error) {
+		return RawResponse{StatusCode: 200, Body: []byte(request.PathParam("id") + "|" + request.PathParam("path"))}, nil
+	}
+	createRouter := func(multiplexer Multiplexer) *Router {
+		return NewRouter().WithMultiplexer(multiplexer).
+			Route(GET, "/users/{id}/", handler).
+			Route(GET, "/users/new/", respondWith("new")).
+			Route(GET, "/files/{path...}", handler)
+	}
+	serveMux, radixMux := createRouter(http.NewServeMux()), createRouter(NewRadixMux())
+	cases := map[string]string{
+		"/users/a%2Fb/":       "a/b|",
+		"/us%65rs/new/":       "new",
+		"/users/caf%C3%A9/":   "café|",
+		"/users/x%25y/":       "x%y|",
+		"/users/x%252Fy/":     "x%2Fy|",
+		"/files/a%2Fb/c%20d":  "|a/b/c d",
+		"/files/docs/a.md":    "|docs/a.md",
+		"/users/a%2Fb/extra/": "not found",
+	}
+	for target, expected := range cases {
+		code, body := serveBody(radixMux, "GET", target, nil)
+		expectedCode, expectedBody := serveBody(serveMux, "GET", target, nil)
+		if code != expectedCode || body != expectedBody {
+			t.Errorf("Expected %s to give %d %q as with http.ServeMux, got %d %q", target, expectedCode, expectedBody, code, body)
+		}
+		if body != expected {
+			t.Errorf(EXPECTED_STRING_ERROR, expected, body)
+		}
+	}
+}
+
+// CreateBenchmarkRouter registers a route table typical of a REST API with a router using multiplexer.
+func CreateBenchmarkRouter(multiplexer Multiplexer) *Router {
+	handler := func(Request) (Response, error) { return NoContent{}, nil }
+	router := NewRouter().WithMultiplexer(multiplexer)
+	for _, resource := range []string{"users", "teams", "projects", "issues", "comments", "labels", "milestones", "releases"} {
+		router.Route(GET, fmt.Sprintf("/%s/", resource), handler)
+		router.Route(POST, fmt.Sprintf("/%s/", resource), handler)
+		router.Route(GET, fmt.Sprintf("/%s/{id}/", resource), handler)
+		router.Route(PUT, fmt.Sprintf("/%s/{id}/", resource), handler)
+		router.Route(DELETE, fmt.Sprintf("/%s/{id}/", resource), handler)
+		router.Route(GET, fmt.Sprintf("/%s/{id}/history/", resource), handler)
+	}
+	return router
+}
+
+func benchmarkMatch(b *testing.B, multiplexer Multiplexer, path string) {
+	CreateBenchmarkRouter(multiplexer)
+	matcher := multiplexer.(matcher)
+	request := CreateMockHTTPRequest(GET, path)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		matcher.Handler(request)
+	}
+}
+
+func benchmarkServe(b *testing.B, multiplexer Multiplexer, path string) {
+	router := CreateBenchmarkRouter(multiplexer)
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	writer := httptest.NewRecorder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		router.ServeHTTP(writer, request)
+	}
+}
+
+func BenchmarkServeMuxMatchStatic(b *testing.B) {
+	benchmarkMatch(b, http.NewServeMux(), "/releases/")
+}
+
+func BenchmarkRadixMuxMatchStatic(b *testing.B) {
+	benchmarkMatch(b, NewRadixMux(), "/releases/")
+}
+
+func BenchmarkServeMuxMatchParam(b *testing.B) {
+	benchmarkMatch(b, http.NewServeMux(), "/releases/42/history/")
+}
+
+func BenchmarkRadixMuxMatchParam(b *testing.B) {
+	benchmarkMatch(b, NewRadixMux(), "/releases/42/history/")
+}
+
+func BenchmarkServeMuxServeParam(b *testing.B) {
+	benchmarkServe(b, http.NewServeMux(), "/releases/42/history/")
+}
+
+func BenchmarkRadixMuxServeParam(b *testing.B) {
+	benchmarkServe(b, NewRadixMux(), "/releases/42/history/")
+}
@@ -0,0 +1,76 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+// RequestIDHeader is the header from which the ID of a request is read.
+const RequestIDHeader = "X-Request-ID"
+
+// PanicReport describes a panic recovered while handling a request.
+type PanicReport struct {
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the panicking goroutine, as formatted by runtime/debug.Stack.
+	Stack []byte
+	// RequestID is the ID of the request being handled, or "" if it has none.
+	RequestID string
+	// Request is the request being handled.
+	Request *http.Request
+}
+
+// PanicReporter is called with every panic recovered from a handler, before the 500 response is written.
+type PanicReporter = func(context.Context, PanicReport)
+
+// SlogPanicReporter returns a PanicReporter that logs panics at error level to the given logger.
+// A nil logger logs to slog.Default at the time of the panic.
+func SlogPanicReporter(logger *slog.Logger) PanicReporter {
+	return func(ctx context.Context, report PanicReport) {
+		target := logger
+		if target == nil {
+			target = slog.Default()
+		}
+		target.ErrorContext(ctx, "panic while handling request",
+			slog.String("panic", fmt.Sprint(report.Value)),
+			slog.String("request_id", report.RequestID),
+			slog.String("method", report.Request.Method),
+			slog.String("path", report.Request.URL.Path),
+			slog.String("stack", string(report.Stack)),
+		)
+	}
+}
+
+// recoverPanic reports a panic recovered while handling request and writes a 500 response.
+// If the server is configured to do so, http.ErrAbortHandler is re-panicked instead so that the
+// http.Server aborts the response.
+func recoverPanic(writer ResponseWriter, request *http.Request, recovered any) {
+	reportPanic(writer, request, recovered)
+	InternalServerError{fmt.Errorf("internal server error")}.Write(writer)
+}
+
+// reportPanic passes a panic recovered while handling request to the PanicReporter of the server.
+// If the server is configured to do so, http.ErrAbortHandler is re-panicked instead.
+func reportPanic(writer ResponseWriter, request *http.Request, recovered any) {
+	settings := settingsFrom(request.Context())
+	if recovered == http.ErrAbortHandler && settings.propagateAbortHandler {
+		panic(recovered)
+	}
+	reporter := settings.panicReporter
+	if reporter == nil {
+		reporter = SlogPanicReporter(nil)
+	}
+	requestID := request.Header.Get(RequestIDHeader)
+	if requestID == "" {
+		requestID = writer.Header().Get(RequestIDHeader)
+	}
+	reporter(request.Context(), PanicReport{
+		Value:     recovered,
+		Stack:     debug.Stack(),
+		RequestID: requestID,
+		Request:   request,
+	})
+}
@@ -0,0 +1,112 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func CreateMockPanicReporter() (PanicReporter, *[]PanicReport) {
+	reports := []PanicReport{}
+	return func(ctx context.Context, report PanicReport) {
+		reports = append(reports, report)
+	}, &reports
+}
+
+func servePanic(server *Server, value any) *httptest.ResponseRecorder {
+	handler := adapt(func(Request) (Response, error) {
+		panic(value)
+	})
+	writer := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, MOCK_PATH, nil)
+	request.Header.Set(RequestIDHeader, "request-1")
+	handler.ServeHTTP(writer, request.WithContext(server.server.BaseContext(nil)))
+	return writer
+}
+
+func TestPanicIsPassedToReporter(t *testing.T) {
+	reporter, reports := CreateMockPanicReporter()
+	writer := servePanic(NewServer("").WithPanicReporter(reporter), "boom")
+	if writer.Code != 500 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 500, writer.Code)
+	}
+	if len(*reports) != 1 {
+		t.Fatalf(EXPECTED_DIGIT_ERROR, 1, len(*reports))
+	}
+	report := (*reports)[0]
+	if report.Value != "boom" {
+		t.Errorf("Expected boom, got %v", report.Value)
+	}
+	if report.RequestID != "request-1" {
+		t.Errorf(EXPECTED_STRING_ERROR, "request-1", report.RequestID)
+	}
+	if report.Request.URL.Path != MOCK_PATH {
+		t.Errorf(EXPECTED_STRING_ERROR, MOCK_PATH, report.Request.URL.Path)
+	}
+	if !strings.Contains(string(report.Stack), "servePanic") {
+		t.Errorf("Expected stack to contain the panicking handler, got %s", report.Stack)
+	}
+}
+
+func TestPanicReportFallsBackToResponseRequestID(t *testing.T) {
+	reporter, reports := CreateMockPanicReporter()
+	server := NewServer("").WithPanicReporter(reporter)
+	handler := adapt(func(Request) (Response, error) {
+		panic("boom")
+	})
+	writer := httptest.NewRecorder()
+	writer.Header().Set(RequestIDHeader, "response-1")
+	request := httptest.NewRequest(http.MethodGet, MOCK_PATH, nil)
+	handler.ServeHTTP(writer, request.WithContext(server.server.BaseContext(nil)))
+	if (*reports)[0].RequestID != "response-1" {
+		t.Errorf(EXPECTED_STRING_ERROR, "response-1", (*reports)[0].RequestID)
+	}
+}
+
+func TestPanicIsLoggedToSlogByDefault(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(buffer, nil)))
+	defer slog.SetDefault(defaultLogger)
+	servePanic(NewServer(""), "boom")
+	output := buffer.String()
+	for _, expected := range []string{"level=ERROR", "panic=boom", "request_id=request-1", "method=GET", "stack="} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected log to contain %s, got %s", expected, output)
+		}
+	}
+}
+
+func TestSlogPanicReporterLogsToGivenLogger(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	reporter := SlogPanicReporter(slog.New(slog.NewJSONHandler(buffer, nil)))
+	servePanic(NewServer("").WithPanicReporter(reporter), "boom")
+	if !strings.Contains(buffer.String(), `"panic":"boom"`) {
+		t.Errorf("Expected log to contain panic, got %s", buffer.String())
+	}
+}
+
+func TestAbortHandlerIsRecoveredByDefault(t *testing.T) {
+	reporter, reports := CreateMockPanicReporter()
+	writer := servePanic(NewServer("").WithPanicReporter(reporter), http.ErrAbortHandler)
+	if writer.Code != 500 || len(*reports) != 1 {
+		t.Errorf("Expected abort to be recovered and reported")
+	}
+}
+
+func TestAbortHandlerIsPropagatedWhenConfigured(t *testing.T) {
+	reporter, reports := CreateMockPanicReporter()
+	defer func() {
+		if recover() != http.ErrAbortHandler {
+			t.Error("Expected http.ErrAbortHandler to be re-panicked")
+		}
+		if len(*reports) != 0 {
+			t.Error("Expected abort not to be reported")
+		}
+	}()
+	servePanic(NewServer("").WithPanicReporter(reporter).WithAbortHandlerPropagation(), http.ErrAbortHandler)
+}
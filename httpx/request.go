@@ -1,6 +1,97 @@
 package httpx
 
-import "net/http"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
 
-// Response is a type alias for http.Response.
+// Request is an http.Request, defined as its own type to add the accessors of this package.
 type Request http.Request
+
+// ErrMissingParameter is returned when a requested parameter is not present on the request.
+var ErrMissingParameter = errors.New("missing parameter")
+
+// ParamError is returned when a path parameter is missing or cannot be parsed.
+// It is written as a BadRequest when returned from a Handler.
+type ParamError struct {
+	Name  string
+	Value string
+	Err   error
+}
+
+func (err *ParamError) Error() string {
+	if errors.Is(err.Err, ErrMissingParameter) {
+		return fmt.Sprintf("path parameter %s: %s", err.Name, err.Err)
+	}
+	return fmt.Sprintf("path parameter %s=%q: %s", err.Name, err.Value, err.Err)
+}
+
+func (err *ParamError) Unwrap() error {
+	return err.Err
+}
+
+func (err *ParamError) Write(writer ResponseWriter) error {
+	return BadRequest{err}.Write(writer)
+}
+
+// Context returns the request's context.
+//
+// see http.Request.Context for more details.
+func (request *Request) Context() context.Context {
+	return (*http.Request)(request).Context()
+}
+
+// PathParam returns the value of the named path parameter.
+// It returns "" if the route has no such parameter.
+func (request *Request) PathParam(name string) string {
+	return (*http.Request)(request).PathValue(name)
+}
+
+// PathInt returns the named path parameter parsed as a base 10 integer.
+func (request *Request) PathInt(name string) (int, error) {
+	value, err := request.requirePathParam(name)
+	if err != nil {
+		return 0, err
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, &ParamError{Name: name, Value: value, Err: errors.Unwrap(err)}
+	}
+	return parsed, nil
+}
+
+// PathUUID returns the named path parameter parsed as a UUID.
+func (request *Request) PathUUID(name string) (UUID, error) {
+	value, err := request.requirePathParam(name)
+	if err != nil {
+		return UUID{}, err
+	}
+	parsed, err := ParseUUID(value)
+	if err != nil {
+		return UUID{}, &ParamError{Name: name, Value: value, Err: err}
+	}
+	return parsed, nil
+}
+
+// AllowedMethods returns the methods allowed for the path of a request that was routed to a
+// MethodNotAllowed handler, or nil for any other request.
+func (request *Request) AllowedMethods() []Method {
+	methods, _ := request.Context().Value(allowedMethodsKey{}).([]Method)
+	return methods
+}
+
+// LastEventID returns the ID of the last Server-Sent Event received by a reconnecting client, or "" if there is none.
+func (request *Request) LastEventID() string {
+	return request.Header.Get(LastEventIDHeader)
+}
+
+func (request *Request) requirePathParam(name string) (string, error) {
+	value := request.PathParam(name)
+	if value == "" {
+		return "", &ParamError{Name: name, Err: ErrMissingParameter}
+	}
+	return value, nil
+}
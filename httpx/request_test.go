@@ -1,8 +1,12 @@
 package httpx
 
 import (
+	"errors"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strconv"
+	"testing"
 )
 
 func CreateMockHTTPRequest(method Method, path string) *http.Request {
@@ -11,3 +15,101 @@ func CreateMockHTTPRequest(method Method, path string) *http.Request {
 		URL:    &url.URL{Path: path},
 	}
 }
+
+func CreateMockRequestWithPathValue(name string, value string) Request {
+	request := CreateMockHTTPRequest(GET, MOCK_PATH)
+	request.SetPathValue(name, value)
+	return Request(*request)
+}
+
+func TestPathParamReturnsValue(t *testing.T) {
+	request := CreateMockRequestWithPathValue("id", "42")
+	if request.PathParam("id") != "42" {
+		t.Errorf(EXPECTED_STRING_ERROR, "42", request.PathParam("id"))
+	}
+}
+
+func TestPathParamReturnsEmptyStringWhenMissing(t *testing.T) {
+	request := CreateMockRequestWithPathValue("id", "42")
+	if request.PathParam("other") != "" {
+		t.Errorf(EXPECTED_STRING_ERROR, "", request.PathParam("other"))
+	}
+}
+
+func TestPathIntParsesValue(t *testing.T) {
+	request := CreateMockRequestWithPathValue("id", "42")
+	value, err := request.PathInt("id")
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if value != 42 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 42, value)
+	}
+}
+
+func TestPathIntReturnsParamErrorForMalformedValue(t *testing.T) {
+	request := CreateMockRequestWithPathValue("id", "forty-two")
+	_, err := request.PathInt("id")
+	var paramErr *ParamError
+	if !errors.As(err, &paramErr) {
+		t.Fatalf("Expected *ParamError, got %v", err)
+	}
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("Expected strconv.ErrSyntax, got %v", paramErr.Err)
+	}
+	expected := `path parameter id="forty-two": invalid syntax`
+	if err.Error() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, err.Error())
+	}
+}
+
+func TestPathIntReturnsParamErrorForMissingValue(t *testing.T) {
+	request := CreateMockRequestWithPathValue("id", "42")
+	_, err := request.PathInt("other")
+	if !errors.Is(err, ErrMissingParameter) {
+		t.Errorf("Expected ErrMissingParameter, got %v", err)
+	}
+	expected := "path parameter other: missing parameter"
+	if err.Error() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, err.Error())
+	}
+}
+
+func TestPathUUIDParsesValue(t *testing.T) {
+	request := CreateMockRequestWithPathValue("id", MOCK_UUID)
+	value, err := request.PathUUID("id")
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if value.String() != MOCK_UUID {
+		t.Errorf(EXPECTED_STRING_ERROR, MOCK_UUID, value.String())
+	}
+}
+
+func TestPathUUIDReturnsParamErrorForMalformedValue(t *testing.T) {
+	request := CreateMockRequestWithPathValue("id", "42")
+	_, err := request.PathUUID("id")
+	if !errors.Is(err, ErrInvalidUUID) {
+		t.Errorf("Expected ErrInvalidUUID, got %v", err)
+	}
+}
+
+func TestPathUUIDReturnsParamErrorForMissingValue(t *testing.T) {
+	request := CreateMockRequestWithPathValue("id", MOCK_UUID)
+	_, err := request.PathUUID("other")
+	if !errors.Is(err, ErrMissingParameter) {
+		t.Errorf("Expected ErrMissingParameter, got %v", err)
+	}
+}
+
+func TestParamErrorIsWrittenAsBadRequest(t *testing.T) {
+	writer := httptest.NewRecorder()
+	(&ParamError{Name: "id", Err: ErrMissingParameter}).Write(writer)
+	if writer.Code != 400 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 400, writer.Code)
+	}
+	expected := "bad request: path parameter id: missing parameter"
+	if writer.Body.String() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, writer.Body.String())
+	}
+}
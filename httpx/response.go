@@ -1,9 +1,13 @@
 package httpx
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type ResponseWriter interface {
@@ -16,42 +20,69 @@ type Response interface {
 	Write(ResponseWriter) error
 }
 
+// RawResponse writes Body as is.
+// Headers replace any values the writer already holds for the same keys.
 type RawResponse struct {
 	StatusCode int
-	Headers    map[string]string
+	Headers    http.Header
 	Body       []byte
 }
 
 func (response RawResponse) Write(writer ResponseWriter) error {
-	writer.WriteHeader(response.StatusCode)
-	for key, value := range response.Headers {
-		writer.Header().Set(key, value)
-	}
+	writeHeader(writer, response.StatusCode, response.Headers, nil)
 	_, err := writer.Write(response.Body)
 	return err
 }
 
+// ObjectResponse writes Body encoded in the format requested by the Accept header of the request.
+// Only JSON is available by default; other encoders are added with Server.WithEncoder, and JSON stays preferred.
+// Responses to requests that accept none of them are written as NotAcceptable. If the preferred encoder fails
+// to encode Body, the next acceptable encoder is tried.
+// The Content-Type defaults to the media type of the encoder and may be overridden through Headers.
 type ObjectResponse struct {
 	StatusCode int
-	Headers    map[string]string
+	Headers    http.Header
 	Body       interface{}
 }
 
 func (response ObjectResponse) Write(writer ResponseWriter) error {
-	serializedBody, err := json.Marshal(response.Body)
-	if err != nil {
-		return InternalServerError{err}.Write(writer)
+	encoders := settingsOf(writer).encoders
+	var accept []string
+	if request := requestOf(writer); request != nil {
+		accept = request.Header.Values("Accept")
 	}
-	return RawResponse{
-		StatusCode: response.StatusCode,
-		Headers:    map[string]string{"Content-Type": "application/json"},
-		Body:       serializedBody,
-	}.Write(writer)
+	ranked := rankEncoders(accept, encoders, response.Body)
+	if len(ranked) == 0 {
+		return NotAcceptable{Available: availableMediaTypes(encoders, response.Body)}.Write(writer)
+	}
+	var serializedBody bytes.Buffer
+	var encoder Encoder
+	var encodeErr error
+	for _, candidate := range ranked {
+		serializedBody.Reset()
+		if err := candidate.Encode(&serializedBody, response.Body); err != nil {
+			if encodeErr == nil {
+				encodeErr = err
+			}
+			continue
+		}
+		encoder = candidate
+		break
+	}
+	if encoder == nil {
+		return InternalServerError{encodeErr}.Write(writer)
+	}
+	if len(encoders) > 1 {
+		addVary(writer.Header(), "Accept")
+	}
+	writeHeader(writer, response.StatusCode, response.Headers, http.Header{"Content-Type": {encoder.MediaType()}})
+	_, err := writer.Write(serializedBody.Bytes())
+	return err
 }
 
 type JSONResponse struct {
 	StatusCode int
-	Headers    map[string]string
+	Headers    http.Header
 	Body       map[string]interface{}
 }
 
@@ -67,9 +98,14 @@ type ErrorResponse struct {
 	StatusCode int
 	Message    string
 	Error      error
+	Headers    http.Header
 }
 
+// Write writes the response as plain text, or as a ProblemResponse if the server is configured to use problem details.
 func (response ErrorResponse) Write(writer ResponseWriter) error {
+	if settingsOf(writer).problemDetails {
+		return problemFor(response).Write(writer)
+	}
 	var body []byte
 	if response.Error == nil {
 		body = []byte(response.Message)
@@ -78,6 +114,7 @@ func (response ErrorResponse) Write(writer ResponseWriter) error {
 	}
 	return RawResponse{
 		StatusCode: response.StatusCode,
+		Headers:    response.Headers,
 		Body:       body,
 	}.Write(writer)
 }
@@ -97,9 +134,9 @@ func (response InternalServerError) Write(writer ResponseWriter) error {
 type ServiceUnavailable struct{}
 
 func (response ServiceUnavailable) Write(writer ResponseWriter) error {
-	return RawResponse{
+	return ErrorResponse{
 		StatusCode: 503,
-		Body:       []byte("service unavailable"),
+		Message:    "service unavailable",
 	}.Write(writer)
 }
 
@@ -114,3 +151,353 @@ func (response BadRequest) Write(writer ResponseWriter) error {
 		Error:      response.Error,
 	}.Write(writer)
 }
+
+// NotAcceptable is written when no representation of a response matches the Accept header of the request.
+type NotAcceptable struct {
+	// Available lists the media types that could have been produced.
+	Available []string
+}
+
+func (response NotAcceptable) Write(writer ResponseWriter) error {
+	var err error
+	if len(response.Available) > 0 {
+		err = fmt.Errorf("available media types are %s", strings.Join(response.Available, ", "))
+	}
+	return ErrorResponse{
+		StatusCode: 406,
+		Message:    "not acceptable",
+		Error:      err,
+	}.Write(writer)
+}
+
+type UnprocessableEntity struct {
+	Fields []FieldError
+}
+
+func (response UnprocessableEntity) Write(writer ResponseWriter) error {
+	if settingsOf(writer).problemDetails {
+		return ProblemResponse{
+			Status:     422,
+			Extensions: map[string]any{"errors": response.Fields},
+		}.Write(writer)
+	}
+	body, err := json.Marshal(struct {
+		Message string       `json:"message"`
+		Errors  []FieldError `json:"errors"`
+	}{"unprocessable entity", response.Fields})
+	if err != nil {
+		return InternalServerError{err}.Write(writer)
+	}
+	return RawResponse{
+		StatusCode: 422,
+		Headers:    http.Header{"Content-Type": {"application/json"}},
+		Body:       body,
+	}.Write(writer)
+}
+
+// Created is written as 201 Created, with the Location of the new resource.
+// A non-nil Body is written as it is by ObjectResponse.
+type Created struct {
+	Location string
+	Body     interface{}
+}
+
+func (response Created) Write(writer ResponseWriter) error {
+	return writeOptionalBody(writer, http.StatusCreated, locationHeader(response.Location), response.Body)
+}
+
+// Accepted is written as 202 Accepted, for requests that will be processed later.
+// Location may point to a resource reporting the progress of the request.
+// A non-nil Body is written as it is by ObjectResponse.
+type Accepted struct {
+	Location string
+	Body     interface{}
+}
+
+func (response Accepted) Write(writer ResponseWriter) error {
+	return writeOptionalBody(writer, http.StatusAccepted, locationHeader(response.Location), response.Body)
+}
+
+type NoContent struct{}
+
+func (response NoContent) Write(writer ResponseWriter) error {
+	return RawResponse{StatusCode: http.StatusNoContent}.Write(writer)
+}
+
+// NotModified is written as 304 Not Modified, without a body.
+// Headers should include those that a 200 response would have carried, such as ETag and Cache-Control.
+type NotModified struct {
+	Headers http.Header
+}
+
+func (response NotModified) Write(writer ResponseWriter) error {
+	return RawResponse{StatusCode: http.StatusNotModified, Headers: response.Headers}.Write(writer)
+}
+
+// MovedPermanently redirects with 301 Moved Permanently. Clients may change the method of the request to GET;
+// see PermanentRedirect to preserve it.
+type MovedPermanently struct {
+	Location string
+}
+
+func (response MovedPermanently) Write(writer ResponseWriter) error {
+	return redirect(writer, http.StatusMovedPermanently, response.Location)
+}
+
+// Found redirects with 302 Found. Clients may change the method of the request to GET;
+// see TemporaryRedirect to preserve it.
+type Found struct {
+	Location string
+}
+
+func (response Found) Write(writer ResponseWriter) error {
+	return redirect(writer, http.StatusFound, response.Location)
+}
+
+// SeeOther redirects with 303 See Other, which clients follow with a GET request,
+// such as after a form has been submitted.
+type SeeOther struct {
+	Location string
+}
+
+func (response SeeOther) Write(writer ResponseWriter) error {
+	return redirect(writer, http.StatusSeeOther, response.Location)
+}
+
+// TemporaryRedirect redirects with 307 Temporary Redirect, which clients follow with the same method and body.
+type TemporaryRedirect struct {
+	Location string
+}
+
+func (response TemporaryRedirect) Write(writer ResponseWriter) error {
+	return redirect(writer, http.StatusTemporaryRedirect, response.Location)
+}
+
+// PermanentRedirect redirects with 308 Permanent Redirect, which clients follow with the same method and body.
+type PermanentRedirect struct {
+	Location string
+}
+
+func (response PermanentRedirect) Write(writer ResponseWriter) error {
+	return redirect(writer, http.StatusPermanentRedirect, response.Location)
+}
+
+// Unauthorized is written as 401 Unauthorized.
+// Challenge is sent in the WWW-Authenticate header, such as `Bearer realm="api"`, and should always be set.
+type Unauthorized struct {
+	Challenge string
+	Error     error
+}
+
+func (response Unauthorized) Write(writer ResponseWriter) error {
+	var headers http.Header
+	if response.Challenge != "" {
+		headers = http.Header{"WWW-Authenticate": {response.Challenge}}
+	}
+	return ErrorResponse{
+		StatusCode: http.StatusUnauthorized,
+		Message:    "unauthorized",
+		Error:      response.Error,
+		Headers:    headers,
+	}.Write(writer)
+}
+
+type Forbidden struct {
+	Error error
+}
+
+func (response Forbidden) Write(writer ResponseWriter) error {
+	return ErrorResponse{
+		StatusCode: http.StatusForbidden,
+		Message:    "forbidden",
+		Error:      response.Error,
+	}.Write(writer)
+}
+
+type NotFound struct {
+	Error error
+}
+
+func (response NotFound) Write(writer ResponseWriter) error {
+	return ErrorResponse{
+		StatusCode: http.StatusNotFound,
+		Message:    "not found",
+		Error:      response.Error,
+	}.Write(writer)
+}
+
+// MethodNotAllowed is written as 405 Method Not Allowed, listing the methods that are allowed in the Allow header.
+type MethodNotAllowed struct {
+	Allowed []Method
+	Error   error
+}
+
+func (response MethodNotAllowed) Write(writer ResponseWriter) error {
+	return ErrorResponse{
+		StatusCode: http.StatusMethodNotAllowed,
+		Message:    "method not allowed",
+		Error:      response.Error,
+		Headers:    http.Header{"Allow": {joinMethods(response.Allowed)}},
+	}.Write(writer)
+}
+
+type Conflict struct {
+	Error error
+}
+
+func (response Conflict) Write(writer ResponseWriter) error {
+	return ErrorResponse{
+		StatusCode: http.StatusConflict,
+		Message:    "conflict",
+		Error:      response.Error,
+	}.Write(writer)
+}
+
+type Gone struct {
+	Error error
+}
+
+func (response Gone) Write(writer ResponseWriter) error {
+	return ErrorResponse{
+		StatusCode: http.StatusGone,
+		Message:    "gone",
+		Error:      response.Error,
+	}.Write(writer)
+}
+
+type PreconditionFailed struct {
+	Error error
+}
+
+func (response PreconditionFailed) Write(writer ResponseWriter) error {
+	return ErrorResponse{
+		StatusCode: http.StatusPreconditionFailed,
+		Message:    "precondition failed",
+		Error:      response.Error,
+	}.Write(writer)
+}
+
+type RequestEntityTooLarge struct {
+	Error error
+}
+
+func (response RequestEntityTooLarge) Write(writer ResponseWriter) error {
+	return ErrorResponse{
+		StatusCode: http.StatusRequestEntityTooLarge,
+		Message:    "request entity too large",
+		Error:      response.Error,
+	}.Write(writer)
+}
+
+// UnsupportedMediaType is written as 415 Unsupported Media Type.
+// Supported lists the media types the request body may have, which are sent in the Accept header.
+type UnsupportedMediaType struct {
+	Supported []string
+	Error     error
+}
+
+func (response UnsupportedMediaType) Write(writer ResponseWriter) error {
+	var headers http.Header
+	if len(response.Supported) > 0 {
+		headers = http.Header{"Accept": {strings.Join(response.Supported, ", ")}}
+	}
+	return ErrorResponse{
+		StatusCode: http.StatusUnsupportedMediaType,
+		Message:    "unsupported media type",
+		Error:      response.Error,
+		Headers:    headers,
+	}.Write(writer)
+}
+
+// TooManyRequests is written as 429 Too Many Requests.
+// A positive RetryAfter is sent in the Retry-After header, rounded up to whole seconds.
+type TooManyRequests struct {
+	RetryAfter time.Duration
+	Error      error
+}
+
+func (response TooManyRequests) Write(writer ResponseWriter) error {
+	var headers http.Header
+	if response.RetryAfter > 0 {
+		seconds := (response.RetryAfter + time.Second - 1) / time.Second
+		headers = http.Header{"Retry-After": {strconv.FormatInt(int64(seconds), 10)}}
+	}
+	return ErrorResponse{
+		StatusCode: http.StatusTooManyRequests,
+		Message:    "too many requests",
+		Error:      response.Error,
+		Headers:    headers,
+	}.Write(writer)
+}
+
+// redirect writes a response without a body that redirects to location.
+func redirect(writer ResponseWriter, statusCode int, location string) error {
+	return RawResponse{StatusCode: statusCode, Headers: locationHeader(location)}.Write(writer)
+}
+
+// locationHeader returns a header holding location, or nil if location is empty.
+func locationHeader(location string) http.Header {
+	if location == "" {
+		return nil
+	}
+	return http.Header{"Location": {location}}
+}
+
+// writeOptionalBody writes body as an ObjectResponse, or a response without a body if body is nil.
+func writeOptionalBody(writer ResponseWriter, statusCode int, headers http.Header, body interface{}) error {
+	if body == nil {
+		return RawResponse{StatusCode: statusCode, Headers: headers}.Write(writer)
+	}
+	return ObjectResponse{StatusCode: statusCode, Headers: headers, Body: body}.Write(writer)
+}
+
+// writeHeader prepares the header of writer and writes the status line.
+// Each key in headers replaces the values the writer already holds for it, keeping every value given.
+// Each key in defaults is set in the same way, unless headers provide it.
+// Vary is the exception: its values are added to those the writer holds, so that negotiated headers are kept.
+// A zero statusCode is written as 200 OK.
+// Headers must be complete before the status line is written, as later changes are not sent to the client.
+func writeHeader(writer ResponseWriter, statusCode int, headers http.Header, defaults http.Header) {
+	setHeaders(writer.Header(), headers, defaults)
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	writer.WriteHeader(statusCode)
+}
+
+// setHeaders sets headers and defaults on header as writeHeader does, without writing the status line.
+func setHeaders(header http.Header, headers http.Header, defaults http.Header) {
+	for key, values := range defaults {
+		header[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+	}
+	for key, values := range headers {
+		if http.CanonicalHeaderKey(key) == "Vary" {
+			for _, value := range values {
+				addVary(header, strings.Split(value, ",")...)
+			}
+			continue
+		}
+		header[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+	}
+}
+
+// addVary adds names to the Vary header of header, skipping those it already lists.
+func addVary(header http.Header, names ...string) {
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" && !varies(header, name) {
+			header.Add("Vary", name)
+		}
+	}
+}
+
+func varies(header http.Header, name string) bool {
+	for _, value := range header.Values("Vary") {
+		for _, listed := range strings.Split(value, ",") {
+			listed = strings.TrimSpace(listed)
+			if listed == "*" || strings.EqualFold(listed, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
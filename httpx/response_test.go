@@ -2,8 +2,10 @@ package httpx
 
 import (
 	"fmt"
+	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 const MOCK_BODY = "Hello, World!"
@@ -42,7 +44,7 @@ func TestRawResponseWritesBody(t *testing.T) {
 func TestRawResponseWritesHeaders(t *testing.T) {
 	response := RawResponse{
 		StatusCode: 500,
-		Headers:    map[string]string{CONTENT_TYPE_HEADER_KEY: CONTENT_TYPE_TEXT},
+		Headers:    http.Header{CONTENT_TYPE_HEADER_KEY: {CONTENT_TYPE_TEXT}},
 		Body:       []byte(""),
 	}
 	writer := httptest.NewRecorder()
@@ -266,3 +268,181 @@ func TestServiceNotAvailableSetsMessage(t *testing.T) {
 		t.Errorf(EXPECTED_STRING_ERROR, SERVICE_UNAVAILABLE, writer.Body.String())
 	}
 }
+
+func TestUnprocessableEntityWritesStatusCode(t *testing.T) {
+	response := UnprocessableEntity{}
+	writer := httptest.NewRecorder()
+	response.Write(writer)
+	if writer.Code != 422 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 422, writer.Code)
+	}
+}
+
+func TestUnprocessableEntityListsFields(t *testing.T) {
+	response := UnprocessableEntity{
+		Fields: []FieldError{{Field: "limit", Location: "query", Rule: "min=1", Message: "must be at least 1"}},
+	}
+	writer := httptest.NewRecorder()
+	response.Write(writer)
+	expected := `{"message":"unprocessable entity","errors":[{"field":"limit","location":"query","rule":"min=1","message":"must be at least 1"}]}`
+	if writer.Body.String() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, writer.Body.String())
+	}
+}
+
+func TestRawResponseSetsHeadersBeforeStatusLine(t *testing.T) {
+	response := RawResponse{
+		StatusCode: 200,
+		Headers:    http.Header{CONTENT_TYPE_HEADER_KEY: {CONTENT_TYPE_TEXT}},
+	}
+	writer := httptest.NewRecorder()
+	response.Write(writer)
+	if writer.Result().Header.Get(CONTENT_TYPE_HEADER_KEY) != CONTENT_TYPE_TEXT {
+		t.Errorf(EXPECTED_STRING_ERROR, CONTENT_TYPE_TEXT, writer.Result().Header.Get(CONTENT_TYPE_HEADER_KEY))
+	}
+}
+
+func TestRawResponseReplacesExistingHeaderValues(t *testing.T) {
+	response := RawResponse{Headers: http.Header{"cache-control": {"no-cache", "private"}}}
+	writer := httptest.NewRecorder()
+	writer.Header().Set("Cache-Control", "no-store")
+	writer.Header().Set("X-Other", "kept")
+	response.Write(writer)
+	if values := writer.Result().Header.Values("Cache-Control"); len(values) != 2 || values[0] != "no-cache" || values[1] != "private" {
+		t.Errorf("Expected [no-cache private], got %v", values)
+	}
+	if writer.Result().Header.Get("X-Other") != "kept" {
+		t.Error("Expected unrelated headers to be kept")
+	}
+}
+
+func TestRawResponseDefaultsToStatusOK(t *testing.T) {
+	writer := httptest.NewRecorder()
+	RawResponse{}.Write(writer)
+	if writer.Code != 200 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 200, writer.Code)
+	}
+}
+
+func TestObjectResponseWritesHeaders(t *testing.T) {
+	response := ObjectResponse{
+		StatusCode: 200,
+		Headers:    http.Header{"X-Custom": {"value"}},
+		Body:       map[string]interface{}{},
+	}
+	writer := httptest.NewRecorder()
+	response.Write(writer)
+	result := writer.Result()
+	if result.Header.Get("X-Custom") != "value" {
+		t.Errorf(EXPECTED_STRING_ERROR, "value", result.Header.Get("X-Custom"))
+	}
+	if result.Header.Get(CONTENT_TYPE_HEADER_KEY) != CONTENT_TYPE_JSON {
+		t.Errorf(EXPECTED_STRING_ERROR, CONTENT_TYPE_JSON, result.Header.Get(CONTENT_TYPE_HEADER_KEY))
+	}
+}
+
+func TestObjectResponseHeadersOverrideDefaults(t *testing.T) {
+	response := ObjectResponse{
+		StatusCode: 200,
+		Headers:    http.Header{CONTENT_TYPE_HEADER_KEY: {"application/vnd.api+json"}},
+		Body:       map[string]interface{}{},
+	}
+	writer := httptest.NewRecorder()
+	response.Write(writer)
+	if writer.Result().Header.Get(CONTENT_TYPE_HEADER_KEY) != "application/vnd.api+json" {
+		t.Errorf(EXPECTED_STRING_ERROR, "application/vnd.api+json", writer.Result().Header.Get(CONTENT_TYPE_HEADER_KEY))
+	}
+}
+
+func TestJsonResponseWritesHeaders(t *testing.T) {
+	response := JSONResponse{
+		StatusCode: 200,
+		Headers:    http.Header{"X-Custom": {"value"}},
+		Body:       map[string]interface{}{},
+	}
+	writer := httptest.NewRecorder()
+	response.Write(writer)
+	if writer.Result().Header.Get("X-Custom") != "value" {
+		t.Errorf(EXPECTED_STRING_ERROR, "value", writer.Result().Header.Get("X-Custom"))
+	}
+}
+
+func TestStatusResponsesWriteStatusCodeMessageAndHeaders(t *testing.T) {
+	tests := map[string]struct {
+		response Response
+		status   int
+		body     string
+		headers  map[string]string
+	}{
+		"created":                  {Created{Location: "/items/1/"}, 201, "", map[string]string{"Location": "/items/1/"}},
+		"accepted":                 {Accepted{Location: "/jobs/1/"}, 202, "", map[string]string{"Location": "/jobs/1/"}},
+		"no content":               {NoContent{}, 204, "", nil},
+		"not modified":             {NotModified{Headers: http.Header{"ETag": {`"v1"`}}}, 304, "", map[string]string{"ETag": `"v1"`}},
+		"moved permanently":        {MovedPermanently{"/new/"}, 301, "", map[string]string{"Location": "/new/"}},
+		"found":                    {Found{"/new/"}, 302, "", map[string]string{"Location": "/new/"}},
+		"see other":                {SeeOther{"/new/"}, 303, "", map[string]string{"Location": "/new/"}},
+		"temporary redirect":       {TemporaryRedirect{"/new/"}, 307, "", map[string]string{"Location": "/new/"}},
+		"permanent redirect":       {PermanentRedirect{"/new/"}, 308, "", map[string]string{"Location": "/new/"}},
+		"unauthorized":             {Unauthorized{Challenge: `Bearer realm="api"`}, 401, "unauthorized", map[string]string{"WWW-Authenticate": `Bearer realm="api"`}},
+		"forbidden":                {Forbidden{}, 403, "forbidden", nil},
+		"not found":                {NotFound{}, 404, "not found", nil},
+		"method not allowed":       {MethodNotAllowed{Allowed: []Method{GET, POST}}, 405, "method not allowed", map[string]string{"Allow": "GET, POST"}},
+		"conflict":                 {Conflict{Error: fmt.Errorf("exists")}, 409, "conflict: exists", nil},
+		"gone":                     {Gone{}, 410, "gone", nil},
+		"precondition failed":      {PreconditionFailed{}, 412, "precondition failed", nil},
+		"request entity too large": {RequestEntityTooLarge{}, 413, "request entity too large", nil},
+		"unsupported media type":   {UnsupportedMediaType{Supported: []string{"application/json", "application/xml"}}, 415, "unsupported media type", map[string]string{"Accept": "application/json, application/xml"}},
+		"too many requests":        {TooManyRequests{RetryAfter: 1500 * time.Millisecond}, 429, "too many requests", map[string]string{"Retry-After": "2"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			writer := httptest.NewRecorder()
+			test.response.Write(writer)
+			if writer.Code != test.status {
+				t.Errorf(EXPECTED_DIGIT_ERROR, test.status, writer.Code)
+			}
+			if writer.Body.String() != test.body {
+				t.Errorf(EXPECTED_STRING_ERROR, test.body, writer.Body.String())
+			}
+			for key, value := range test.headers {
+				if writer.Header().Get(key) != value {
+					t.Errorf(EXPECTED_STRING_ERROR, value, writer.Header().Get(key))
+				}
+			}
+		})
+	}
+}
+
+func TestStatusResponsesOmitEmptyHeaders(t *testing.T) {
+	for _, response := range []Response{Created{}, Unauthorized{}, UnsupportedMediaType{}, TooManyRequests{}} {
+		writer := httptest.NewRecorder()
+		response.Write(writer)
+		for _, key := range []string{"Location", "WWW-Authenticate", "Accept", "Retry-After"} {
+			if _, ok := writer.Header()[key]; ok {
+				t.Errorf("Expected no %s header for %T", key, response)
+			}
+		}
+	}
+}
+
+func TestCreatedWritesBodyAsObject(t *testing.T) {
+	writer := httptest.NewRecorder()
+	Created{Location: "/items/1/", Body: map[string]int{"id": 1}}.Write(writer)
+	if writer.Code != 201 || writer.Body.String() != `{"id":1}` {
+		t.Errorf("Expected 201 with JSON body, got %d %s", writer.Code, writer.Body.String())
+	}
+	if writer.Header().Get(CONTENT_TYPE_HEADER_KEY) != CONTENT_TYPE_JSON {
+		t.Errorf(EXPECTED_STRING_ERROR, CONTENT_TYPE_JSON, writer.Header().Get(CONTENT_TYPE_HEADER_KEY))
+	}
+}
+
+func TestErrorResponseHeadersReachProblemDetails(t *testing.T) {
+	writer, recorder := CreateProblemDetailsWriter()
+	MethodNotAllowed{Allowed: []Method{GET}}.Write(writer)
+	if recorder.Header().Get("Allow") != "GET" {
+		t.Errorf(EXPECTED_STRING_ERROR, "GET", recorder.Header().Get("Allow"))
+	}
+	if recorder.Header().Get(CONTENT_TYPE_HEADER_KEY) != problemContentType {
+		t.Errorf(EXPECTED_STRING_ERROR, problemContentType, recorder.Header().Get(CONTENT_TYPE_HEADER_KEY))
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"slices"
+	"strings"
+	"unicode"
 )
 
 type Multiplexer interface {
@@ -11,54 +14,464 @@ type Multiplexer interface {
 	ServeHTTP(writer http.ResponseWriter, request *http.Request)
 }
 
+// matcher is implemented by multiplexers that can report the pattern matching a request without serving it,
+// such as http.ServeMux. The pattern is "" if no route matches the request.
+type matcher interface {
+	Handler(request *http.Request) (handler http.Handler, pattern string)
+}
+
+// valueMatcher is implemented by multiplexers that can set the values of the wildcards of the pattern matching
+// a request while matching it, such as RadixMux, so that the request is served without being matched again.
+type valueMatcher interface {
+	match(request *http.Request) (handler http.Handler, pattern string)
+}
+
 type Router struct {
-	multiplexer Multiplexer
+	multiplexer      Multiplexer
+	errorMappers     []ErrorMapper
+	notFound         Handler
+	methodNotAllowed Handler
+	trailingSlash    TrailingSlashPolicy
+	// versions are the versions of the API served by the router, and defaultVersion the name of the default one.
+	versions       []*apiVersion
+	defaultVersion string
+	// paths holds the routes registered for each path, keyed by the pattern of the path, so that paths which
+	// differ only in the names of their parameters share their routes.
+	paths map[string]*pathRoutes
+	// links holds the routers linked at each path, keyed by the pattern of the path.
+	links map[string]*linkedRouters
+	// registrations are the patterns registered with the multiplexer, in the order of registration.
+	registrations []registration
+	// entries are the routes and linked routers, in the order of registration.
+	entries []routeEntry
+	// middleware is applied to every request served by the router, in the order in which it was added.
+	middleware []Middleware
+	// handler is the middleware chain around dispatch, or nil if the router has no middleware.
+	handler http.Handler
+}
+
+// registration is a pattern registered with the multiplexer of a router.
+type registration struct {
+	pattern string
+	handler http.Handler
+}
+
+// linkedRouters are the routers linked at a single path, which differ in their conditions.
+type linkedRouters struct {
+	// pattern is the path registered with the multiplexer, which is the first path at which a router was linked.
+	pattern    string
+	candidates []candidate
+}
+
+// pathRoutes are the routes registered for a single path.
+type pathRoutes struct {
+	// pattern is the pattern registered with the multiplexer, which is the first path registered without its
+	// constraints and, if it ends in a "/", followed by {$} so that it only matches that path.
+	pattern string
+	// parsed is pattern, parsed once to match request paths against it.
+	parsed pattern
+	// methods are the methods registered for the path, in the order of registration.
+	methods []Method
+	// candidates are the routes registered for each method, in the order of registration.
+	candidates map[Method][]candidate
 }
 
 func NewRouter() *Router {
 	multiplexer := Multiplexer(http.NewServeMux())
-	return &Router{multiplexer}
+	return &Router{multiplexer: multiplexer, paths: map[string]*pathRoutes{}}
 }
 
 // Route registers a handler for the given method and path.
-// The path must start with a "/" and end with a "/".
+// The path must start with a "/" and end with a "/", or with a {name...} wildcard that matches the rest of the path.
 // The path must not contain spaces.
 // The path must not contain consecutive slashes.
-func (router *Router) Route(method Method, path string, handler Handler) *Router {
+// The path must not use the same parameter name more than once.
+// Paths ending in a "/" match that path only; see TrailingSlash for requests that lack the trailing slash.
+// Wildcards may be constrained, as in {id:int}, {id:uuid} or {slug:[a-z-]+}; requests that do not satisfy
+// the constraints fall through to the next route registered for the path, and are otherwise not found.
+// Route panics with a RouteConflictError if a route with the same method, or a GET route for a HEAD route and
+// the reverse, matches the same requests, or if the path is below a linked router; see Router.Validate.
+// GET routes also answer HEAD requests, and OPTIONS requests are answered with 204 No Content and an Allow
+// header, unless a HEAD or OPTIONS route is registered for the path.
+// Options attach information to the route, which is listed by Routes.
+func (router *Router) Route(method Method, path string, handler Handler, options ...RouteOption) *Router {
 	validate(path)
-	pattern := fmt.Sprintf("%s %s", method, path)
-	router.multiplexer.Handle(pattern, adapt(handler))
+	router.handle(newRouteInfo(method, path, handler, options), adapt(handler))
 	return router
 }
 
+// handle registers handler for the method and path of a route with the multiplexer, and records the route.
+// The first route for a method and path registers a dispatcher for the method and path, which serves the
+// routes registered for them in order.
+func (router *Router) handle(info RouteInfo, handler http.Handler) {
+	method, path := info.Method, info.Path
+	if info.Name != "" {
+		for _, entry := range router.entries {
+			if entry.linked == nil && entry.info.Name == info.Name {
+				panic(fmt.Sprintf("route name %q is already used by %s %s", info.Name, entry.info.Method, entry.info.Path))
+			}
+		}
+	}
+	router.checkConflicts(routeEntry{info: info})
+	router.entries = append(router.entries, routeEntry{info: info})
+	key := parsePattern(exactPattern(path)).String()
+	routes, ok := router.paths[key]
+	if !ok {
+		routes = &pathRoutes{pattern: exactPattern(stripConstraints(path)), candidates: map[Method][]candidate{}}
+		routes.parsed = parsePattern(routes.pattern)
+		router.paths[key] = routes
+	}
+	if len(routes.candidates[method]) == 0 {
+		var dispatcher http.Handler = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			serveCandidates(routes.candidates[method], writer, request)
+		})
+		switch method {
+		case GET:
+			dispatcher = headless(dispatcher)
+		case OPTIONS:
+			dispatcher = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				router.serveOptions(routes, writer, request)
+			})
+		}
+		router.register(fmt.Sprintf("%s %s", method, routes.pattern), dispatcher)
+		routes.methods = append(routes.methods, method)
+	}
+	routes.candidates[method] = append(routes.candidates[method], newCandidate(info, routes.pattern, handler))
+}
+
+// register registers handler for pattern with the multiplexer, and records it so that it can be registered
+// with another multiplexer by WithMultiplexer.
+func (router *Router) register(pattern string, handler http.Handler) {
+	router.registrations = append(router.registrations, registration{pattern: pattern, handler: handler})
+	router.multiplexer.Handle(pattern, handler)
+}
+
+// WithMultiplexer replaces the multiplexer of the router, such as with a RadixMux, and registers the routes
+// and linked routers of the router with it. Routers use an http.ServeMux by default.
+func (router *Router) WithMultiplexer(multiplexer Multiplexer) *Router {
+	router.multiplexer = multiplexer
+	for _, registration := range router.registrations {
+		multiplexer.Handle(registration.pattern, registration.handler)
+	}
+	return router
+}
+
+// serveOptions serves an OPTIONS request with the OPTIONS routes registered for routes, after setting the Allow
+// header to the methods allowed for its path. Requests that satisfy none of the routes are answered with
+// 204 No Content.
+func (router *Router) serveOptions(routes *pathRoutes, writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Allow", joinMethods(router.allowedMethods(request.URL.EscapedPath())))
+	if handler := matchCandidate(routes.candidates[OPTIONS], request); handler != nil {
+		handler.ServeHTTP(writer, request)
+		return
+	}
+	NoContent{}.Write(writer)
+}
+
+// answerOptions answers an OPTIONS request for a path with routes but no OPTIONS route, with 204 No Content
+// and an Allow header listing the methods registered for the path. It reports false for other requests,
+// which are left to the multiplexer. Paths that lack the trailing slash of a route are answered according
+// to the TrailingSlashPolicy.
+func (router *Router) answerOptions(writer http.ResponseWriter, request *http.Request) bool {
+	path := request.URL.EscapedPath()
+	matching := router.matchingRoutes(path)
+	if len(matching) == 0 {
+		if strings.HasSuffix(path, "/") || len(router.matchingRoutes(path+"/")) == 0 {
+			return false
+		}
+		serveWithoutSlash(http.HandlerFunc(router.dispatch), writer, request)
+		return true
+	}
+	for _, routes := range matching {
+		if len(routes.candidates[OPTIONS]) > 0 {
+			return false
+		}
+	}
+	writer.Header().Set("Allow", joinMethods(allowed(matching)))
+	NoContent{}.Write(writer)
+	return true
+}
+
+// matchingRoutes returns the routes registered for the paths that match path, an escaped request path.
+// Only the candidates whose constraints are satisfied by path are kept, and routes left without any are omitted.
+func (router *Router) matchingRoutes(path string) []*pathRoutes {
+	var matching []*pathRoutes
+	for _, routes := range router.paths {
+		if !routes.parsed.matches(path) {
+			continue
+		}
+		values := pathValues(routes.pattern, path)
+		satisfied := &pathRoutes{pattern: routes.pattern, candidates: map[Method][]candidate{}}
+		for _, method := range routes.methods {
+			for _, candidate := range routes.candidates[method] {
+				if candidate.satisfies(values) {
+					satisfied.candidates[method] = append(satisfied.candidates[method], candidate)
+				}
+			}
+			if len(satisfied.candidates[method]) > 0 {
+				satisfied.methods = append(satisfied.methods, method)
+			}
+		}
+		if len(satisfied.methods) > 0 {
+			matching = append(matching, satisfied)
+		}
+	}
+	return matching
+}
+
+// allowedMethods returns the methods of the routes registered for the paths that match path, an escaped
+// request path, as listed by allowed.
+func (router *Router) allowedMethods(path string) []Method {
+	return allowed(router.matchingRoutes(path))
+}
+
+// allowed returns the methods registered for any of the routes, which include HEAD for GET routes other than
+// WebSocket routes, and OPTIONS.
+// It returns nil if there are no routes.
+func allowed(routes []*pathRoutes) []Method {
+	var allowed []Method
+	for _, method := range methods {
+		for _, routes := range routes {
+			if slices.ContainsFunc(routes.methods, func(registered Method) bool {
+				return method == registered || method == OPTIONS ||
+					(method == HEAD && registered == GET && answersHead(routes.candidates[GET]))
+			}) {
+				allowed = append(allowed, method)
+				break
+			}
+		}
+	}
+	return allowed
+}
+
 // Link links the otherRouter router to the path.
-// The path must not already be handled by the router.
+// The path must not already be handled by the router: Link panics with a RouteConflictError if a route or
+// another linked router of the router matches requests below the path.
 // When path == "/", this is equivalent to merging the routers.
-func (router *Router) Link(path string, otherRouter *Router) *Router {
-	if path == "/" {
-		router.multiplexer.Handle(path, otherRouter)
-		return router
+// Options such as Host restrict the requests passed to the linked router, so that several routers may be linked
+// at the same path; they are tried in the order in which they were linked.
+func (router *Router) Link(path string, otherRouter *Router, options ...RouteOption) *Router {
+	validatePrefix(path)
+	info := newRouteInfo("", path, nil, options)
+	entry := routeEntry{info: info, linked: otherRouter}
+	router.checkConflicts(entry)
+	router.entries = append(router.entries, entry)
+	var handler http.Handler = otherRouter
+	if path != "/" {
+		handler = http.StripPrefix(path[:len(path)-1], otherRouter)
 	}
-	validate(path)
-	prefix := path[:len(path)-1]
-	router.multiplexer.Handle(path, http.StripPrefix(prefix, otherRouter))
+	if router.links == nil {
+		router.links = map[string]*linkedRouters{}
+	}
+	key := parsePattern(path).String()
+	links, ok := router.links[key]
+	if !ok {
+		links = &linkedRouters{pattern: path}
+		router.links[key] = links
+		router.register(path, http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			serveCandidates(links.candidates, writer, request)
+		}))
+	}
+	links.candidates = append(links.candidates, newCandidate(info, links.pattern, handler))
+	return router
+}
+
+// Merge merges the routes of otherRouter into the router, as if it was linked at "/".
+// Options restrict the requests passed to otherRouter, as for Link.
+func (router *Router) Merge(otherRouter *Router, options ...RouteOption) *Router {
+	return router.Link("/", otherRouter, options...)
+}
+
+// MapError registers an ErrorMapper for errors returned by handlers of this router and any routers linked to it.
+// Mappers are consulted in the order in which they were registered, before those of enclosing routers and the server.
+func (router *Router) MapError(mapper ErrorMapper) *Router {
+	router.errorMappers = append(router.errorMappers, mapper)
+	return router
+}
+
+// NotFound sets the handler for requests that match no route of this router and any routers linked to it
+// that do not set their own. By default, such requests are answered with a NotFound response.
+func (router *Router) NotFound(handler Handler) *Router {
+	router.notFound = handler
+	return router
+}
+
+// MethodNotAllowed sets the handler for requests whose path matches a route of this router, but not their method.
+// It also applies to any routers linked to it that do not set their own. The Allow header lists the allowed
+// methods before the handler is called, and Request.AllowedMethods returns them.
+// By default, such requests are answered with a MethodNotAllowed response.
+func (router *Router) MethodNotAllowed(handler Handler) *Router {
+	router.methodNotAllowed = handler
+	return router
+}
+
+// Use adds a middleware that is applied to every request served by the router, including those served by
+// routers linked to it and those that match no route. Middleware runs in the order in which it was added,
+// after the middleware of the routers this router is linked to.
+func (router *Router) Use(middleware Middleware) *Router {
+	router.middleware = append(router.middleware, middleware)
+	var handler http.Handler = http.HandlerFunc(router.dispatch)
+	for i := len(router.middleware) - 1; i >= 0; i-- {
+		handler = router.middleware[i](handler)
+	}
+	router.handler = handler
 	return router
 }
 
-func (router *Router) Merge(otherRouter *Router) *Router {
-	return router.Link("/", otherRouter)
+// Group creates a router linked at prefix that applies the given middleware, in order, to its routes only.
+// Routes are registered on the returned router with paths relative to prefix.
+func (router *Router) Group(prefix string, middleware ...Middleware) *Router {
+	group := NewRouter()
+	for _, middleware := range middleware {
+		group.Use(middleware)
+	}
+	router.Link(prefix, group)
+	return group
 }
 
 func (router *Router) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
-	router.multiplexer.ServeHTTP(writer, request)
+	state := routingFrom(request.Context())
+	if state == nil {
+		state = &routing{}
+		request = request.WithContext(withRouting(request.Context(), state))
+	}
+	state.enter(router, request.URL.EscapedPath())
+	defer state.leave()
+	if router.handler != nil {
+		router.handler.ServeHTTP(writer, request)
+		return
+	}
+	router.dispatch(writer, request)
 }
 
-func validate(path string) {
-	if path == "/" {
+// dispatch serves a request with the route that matches it, or as an unmatched request.
+// OPTIONS requests for paths without an OPTIONS route are answered by the router.
+func (router *Router) dispatch(writer http.ResponseWriter, request *http.Request) {
+	if request.Method == string(OPTIONS) && router.answerOptions(writer, request) {
+		return
+	}
+	var handler http.Handler = router.multiplexer
+	var pattern string
+	switch multiplexer := router.multiplexer.(type) {
+	case valueMatcher:
+		handler, pattern = multiplexer.match(request)
+	case matcher:
+		// The multiplexer sets the values of wildcards when it serves the request, so it matches it again.
+		_, pattern = multiplexer.Handler(request)
+	default:
+		router.multiplexer.ServeHTTP(writer, request)
+		return
+	}
+	if pattern == "" {
+		// Paths routed for other methods are answered as MethodNotAllowed rather than by a version.
+		if len(router.scopeAllowedMethods(request)) > 0 || !router.serveVersion(writer, request) {
+			router.serveUnmatched(writer, request)
+		}
+		return
+	}
+	if redirectsToSlash(pattern, request.URL.EscapedPath()) {
+		serveWithoutSlash(http.HandlerFunc(router.dispatch), writer, request)
+		return
+	}
+	handler.ServeHTTP(writer, request)
+}
+
+// scopeAllowedMethods returns the methods allowed for request by the routes of the router and of the routers
+// it is linked to, each matched against the path it received, so that a router merged into another does not
+// answer requests for the routes of the other as not found.
+func (router *Router) scopeAllowedMethods(request *http.Request) []Method {
+	routes := router.matchingRoutes(request.URL.EscapedPath())
+	for scope := routerScopeFrom(request.Context()); scope != nil; scope = scope.parent {
+		if scope.router != router {
+			routes = append(routes, scope.router.matchingRoutes(scope.path)...)
+		}
+	}
+	return allowed(routes)
+}
+
+// serveUnmatched answers a request that matches no route, using the NotFound and MethodNotAllowed handlers
+// of the innermost router that sets them.
+func (router *Router) serveUnmatched(writer http.ResponseWriter, request *http.Request) {
+	allowed := router.scopeAllowedMethods(request)
+	if len(allowed) == 0 {
+		serveNotFound(writer, request)
 		return
 	}
-	regex := `^\/(?:[^\/\s{}]+|{[^\/\s{}]+})*(?:\/(?:[^\/\s{}]+|{[^\/\s{}]+}))*\/$`
-	if !regexp.MustCompile(regex).MatchString(path) {
-		panic("path is invalid")
+	scope := routerScopeFrom(request.Context())
+	handler := func(Request) (Response, error) { return MethodNotAllowed{Allowed: allowed}, nil }
+	for ; scope != nil; scope = scope.parent {
+		if scope.router.methodNotAllowed != nil {
+			handler = scope.router.methodNotAllowed
+			break
+		}
+	}
+	writer.Header().Set("Allow", joinMethods(allowed))
+	request = request.WithContext(withAllowedMethods(request.Context(), allowed))
+	adapt(handler).ServeHTTP(writer, request)
+}
+
+// serveNotFound answers a request that matches no route, using the NotFound handler of the innermost router that sets one.
+func serveNotFound(writer http.ResponseWriter, request *http.Request) {
+	handler := func(Request) (Response, error) { return NotFound{}, nil }
+	for scope := routerScopeFrom(request.Context()); scope != nil; scope = scope.parent {
+		if scope.router.notFound != nil {
+			handler = scope.router.notFound
+			break
+		}
+	}
+	adapt(handler).ServeHTTP(writer, request)
+}
+
+var pathRegex = regexp.MustCompile(`^\/(?:(?:[^\/\s{}]+|{[A-Za-z_]\w*(?::[^\/\s{}]+)?})\/)*(?:{[A-Za-z_]\w*\.\.\.})?$`)
+
+// validate panics if path is not a valid route path. Paths consist of segments, each followed by a "/".
+// Segments are literals or wildcards: {name} matches any segment, and {name:constraint} only segments that
+// satisfy the constraint, which is int, uuid or a regular expression without braces.
+// The path may end in a {name...} wildcard instead of a "/", which matches the rest of the path.
+func validate(path string) {
+	if !pathRegex.MatchString(path) {
+		panic(fmt.Sprintf("path %s is invalid: %s", path, invalidPathReason(path)))
+	}
+	seen := map[string]bool{}
+	for _, param := range parseParams(path) {
+		if seen[param.name] {
+			panic(fmt.Sprintf("path %s is invalid: parameter %s is used more than once", path, param.name))
+		}
+		seen[param.name] = true
+	}
+}
+
+var segmentRegex = regexp.MustCompile(`^(?:[^\/\s{}]+|{[A-Za-z_]\w*(?::[^\/\s{}]+)?})$`)
+
+// invalidPathReason returns the reason why path, which does not match pathRegex, is not a valid route path.
+func invalidPathReason(path string) string {
+	switch {
+	case !strings.HasPrefix(path, "/"):
+		return "it must start with a /"
+	case strings.IndexFunc(path, unicode.IsSpace) >= 0:
+		return "it must not contain spaces"
+	case strings.Contains(path, "//"):
+		return "it must not contain consecutive slashes"
+	}
+	segments := strings.Split(path[1:], "/")
+	for _, segment := range segments[:len(segments)-1] {
+		if !segmentRegex.MatchString(segment) {
+			return fmt.Sprintf("segment %s is neither a literal nor a {name} or {name:constraint} wildcard", segment)
+		}
+	}
+	return "it must end in a / or in a {name...} wildcard"
+}
+
+// validatePrefix panics if path is not a valid path at which to link a router.
+// Prefixes must end in a "/", and their wildcards must not have constraints, which are not enforced.
+func validatePrefix(path string) {
+	validate(path)
+	if !strings.HasSuffix(path, "/") {
+		panic(fmt.Sprintf("path %s is invalid: prefixes must end in a /", path))
+	}
+	for _, param := range parseParams(path) {
+		if param.constraint != "" {
+			panic(fmt.Sprintf("path %s is invalid: the constraint of parameter %s would not be enforced", path, param.name))
+		}
 	}
 }
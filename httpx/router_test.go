@@ -1,8 +1,11 @@
 package httpx
 
 import (
+	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 const ROOT_PATH = "/root/"
@@ -69,6 +72,26 @@ func TestValidatePathMustNotBeEmpty(t *testing.T) {
 	validate("")
 }
 
+func TestValidatePathNamesThePathAndReason(t *testing.T) {
+	cases := map[string]string{
+		"some/illegal/path/":   "path some/illegal/path/ is invalid: it must start with a /",
+		"/some illegal path/":  "path /some illegal path/ is invalid: it must not contain spaces",
+		"/some//illegal/path/": "path /some//illegal/path/ is invalid: it must not contain consecutive slashes",
+		"/some/illegal/path":   "path /some/illegal/path is invalid: it must end in a / or in a {name...} wildcard",
+		"/users/{1d}/":         "path /users/{1d}/ is invalid: segment {1d} is neither a literal nor a {name} or {name:constraint} wildcard",
+	}
+	for path, expected := range cases {
+		func() {
+			defer func() {
+				if recovered := recover(); recovered != expected {
+					t.Errorf(EXPECTED_STRING_ERROR, expected, recovered)
+				}
+			}()
+			validate(path)
+		}()
+	}
+}
+
 func TestCanCreateRouter(t *testing.T) {
 	router := NewRouter()
 	if router == nil {
@@ -122,3 +145,382 @@ func TestMergingARouter(t *testing.T) {
 		t.Errorf("Root called %d times!", otherDetails.HandlerCallCount)
 	}
 }
+
+func TestMergedRoutersAnswerMethodNotAllowedForEnclosingRoutes(t *testing.T) {
+	handler, _ := CreateMockHandler()
+	inner := NewRouter().Route(PUT, MOCK_PATH, handler)
+	router := NewRouter().Route(GET, ROOT_PATH, handler).Merge(inner)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(POST, ROOT_PATH))
+	if recorder.Code != 405 || recorder.Header().Get("Allow") != "GET, HEAD, OPTIONS" {
+		t.Errorf("Expected 405 with Allow GET, HEAD, OPTIONS, got %d %q", recorder.Code, recorder.Header().Get("Allow"))
+	}
+
+	inner.Route(DELETE, ROOT_PATH, handler)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(POST, ROOT_PATH))
+	if recorder.Code != 405 || recorder.Header().Get("Allow") != "GET, DELETE, HEAD, OPTIONS" {
+		t.Errorf("Expected 405 with Allow GET, DELETE, HEAD, OPTIONS, got %d %q", recorder.Code, recorder.Header().Get("Allow"))
+	}
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(POST, "/missing/"))
+	if recorder.Code != 404 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 404, recorder.Code)
+	}
+}
+
+func TestUnmatchedPathIsAnsweredWithNotFound(t *testing.T) {
+	router := NewRouter()
+	handler, _ := CreateMockHandler()
+	router.Route(GET, MOCK_PATH, handler)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(GET, "/missing/"))
+	if recorder.Code != 404 || recorder.Body.String() != "not found" {
+		t.Errorf("Expected 404 not found, got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestUnmatchedMethodIsAnsweredWithMethodNotAllowed(t *testing.T) {
+	router := NewRouter()
+	handler, _ := CreateMockHandler()
+	router.Route(GET, MOCK_PATH, handler)
+	router.Route(DELETE, MOCK_PATH, handler)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(POST, MOCK_PATH))
+	if recorder.Code != 405 || recorder.Body.String() != "method not allowed" {
+		t.Errorf("Expected 405 method not allowed, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if allow := recorder.Header().Get("Allow"); allow != "GET, DELETE, HEAD, OPTIONS" {
+		t.Errorf(EXPECTED_STRING_ERROR, "GET, DELETE, HEAD, OPTIONS", allow)
+	}
+}
+
+func TestNotFoundHandlerIsCalledForUnmatchedPaths(t *testing.T) {
+	router := NewRouter().NotFound(func(request Request) (Response, error) {
+		return RawResponse{StatusCode: 404, Body: []byte("no " + request.URL.Path)}, nil
+	})
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(GET, "/missing/"))
+	if recorder.Body.String() != "no /missing/" {
+		t.Errorf(EXPECTED_STRING_ERROR, "no /missing/", recorder.Body.String())
+	}
+}
+
+func TestMethodNotAllowedHandlerReceivesAllowedMethods(t *testing.T) {
+	handler, _ := CreateMockHandler()
+	router := NewRouter().Route(PUT, MOCK_PATH, handler)
+	var allowed []Method
+	router.MethodNotAllowed(func(request Request) (Response, error) {
+		allowed = request.AllowedMethods()
+		return Conflict{}, nil
+	})
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(GET, MOCK_PATH))
+	if len(allowed) != 2 || allowed[0] != PUT || allowed[1] != OPTIONS {
+		t.Errorf("Expected [PUT OPTIONS], got %v", allowed)
+	}
+	if recorder.Code != 409 || recorder.Header().Get("Allow") != "PUT, OPTIONS" {
+		t.Errorf("Expected 409 with Allow PUT, OPTIONS, got %d %q", recorder.Code, recorder.Header().Get("Allow"))
+	}
+}
+
+func TestLinkedRoutersInheritUnmatchedHandlers(t *testing.T) {
+	handler, _ := CreateMockHandler()
+	inner := NewRouter().Route(GET, MOCK_PATH, handler)
+	router := NewRouter().Link(MOCK_LINK, inner)
+	router.NotFound(func(Request) (Response, error) { return RawResponse{StatusCode: 404, Body: []byte("outer")}, nil })
+	router.MethodNotAllowed(func(Request) (Response, error) { return RawResponse{StatusCode: 405, Body: []byte("outer")}, nil })
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(GET, "/link/missing/"))
+	if recorder.Code != 404 || recorder.Body.String() != "outer" {
+		t.Errorf("Expected outer 404, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(POST, MOCK_LINKED_PATH))
+	if recorder.Code != 405 || recorder.Body.String() != "outer" || recorder.Header().Get("Allow") != "GET, HEAD, OPTIONS" {
+		t.Errorf("Expected outer 405, got %d %s %q", recorder.Code, recorder.Body.String(), recorder.Header().Get("Allow"))
+	}
+
+	inner.NotFound(func(Request) (Response, error) { return RawResponse{StatusCode: 404, Body: []byte("inner")}, nil })
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(GET, "/link/missing/"))
+	if recorder.Body.String() != "inner" {
+		t.Errorf(EXPECTED_STRING_ERROR, "inner", recorder.Body.String())
+	}
+}
+
+func TestDeeplyLinkedRoutersInheritUnmatchedHandlers(t *testing.T) {
+	router := NewRouter().NotFound(func(Request) (Response, error) { return RawResponse{StatusCode: 404, Body: []byte("outer")}, nil })
+	inner := router
+	for i := 0; i < 6; i++ {
+		linked := NewRouter()
+		inner.Link(MOCK_LINK, linked)
+		inner = linked
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(GET, strings.Repeat("/link", 6)+"/missing/"))
+	if recorder.Code != 404 || recorder.Body.String() != "outer" {
+		t.Errorf("Expected outer 404, got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestUnmatchedResponsesUseProblemDetails(t *testing.T) {
+	router := NewRouter()
+	request := CreateMockHTTPRequest(GET, "/missing/")
+	request = request.WithContext(withSettings(request.Context(), &settings{problemDetails: true}))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != 404 || recorder.Header().Get(CONTENT_TYPE_HEADER_KEY) != problemContentType {
+		t.Errorf("Expected 404 problem, got %d %q", recorder.Code, recorder.Header().Get(CONTENT_TYPE_HEADER_KEY))
+	}
+}
+
+func TestGetRoutesAnswerHeadWithoutBody(t *testing.T) {
+	router := NewRouter().Route(GET, MOCK_PATH, func(Request) (Response, error) {
+		return RawResponse{StatusCode: 202, Headers: http.Header{"X-Custom": {"value"}}, Body: []byte(MOCK_BODY)}, nil
+	})
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(HEAD, MOCK_PATH))
+	if recorder.Code != 202 || recorder.Body.Len() != 0 {
+		t.Errorf("Expected 202 without body, got %d %q", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Header().Get("X-Custom") != "value" || recorder.Header().Get("Content-Length") != "13" {
+		t.Errorf("Expected headers of the GET response, got %v", recorder.Header())
+	}
+}
+
+// MockDeadlineWriter records the write deadline set through an http.ResponseController.
+type MockDeadlineWriter struct {
+	*httptest.ResponseRecorder
+	deadline time.Time
+}
+
+func (writer *MockDeadlineWriter) SetWriteDeadline(deadline time.Time) error {
+	writer.deadline = deadline
+	return nil
+}
+
+func TestHeadResponsesUnwrapToUnderlyingWriter(t *testing.T) {
+	deadline := time.Now().Add(time.Minute)
+	handler := headless(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if err := http.NewResponseController(writer).SetWriteDeadline(deadline); err != nil {
+			t.Errorf("Expected deadline to be set, got %v", err)
+		}
+	}))
+	writer := &MockDeadlineWriter{ResponseRecorder: httptest.NewRecorder()}
+	handler.ServeHTTP(writer, CreateMockHTTPRequest(HEAD, MOCK_PATH))
+	if !writer.deadline.Equal(deadline) {
+		t.Errorf("Expected deadline %v, got %v", deadline, writer.deadline)
+	}
+}
+
+func TestExplicitHeadRouteTakesPrecedence(t *testing.T) {
+	getHandler, getDetails := CreateMockHandler()
+	headHandler, headDetails := CreateMockHandler()
+	router := NewRouter().Route(GET, MOCK_PATH, getHandler).Route(HEAD, MOCK_PATH, headHandler)
+	router.ServeHTTP(httptest.NewRecorder(), CreateMockHTTPRequest(HEAD, MOCK_PATH))
+	if getDetails.HandlerCallCount != 0 || headDetails.HandlerCallCount != 1 {
+		t.Errorf("Expected HEAD handler to be called, got GET %d HEAD %d", getDetails.HandlerCallCount, headDetails.HandlerCallCount)
+	}
+}
+
+func TestHeadResponsesFlushStatusLine(t *testing.T) {
+	router := NewRouter().Route(GET, MOCK_PATH, func(Request) (Response, error) {
+		return StreamResponse{StatusCode: 206, Items: CreateMockItems(1, 2)}, nil
+	})
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodHead, MOCK_PATH, nil)
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != 206 || !recorder.Flushed || recorder.Body.Len() != 0 {
+		t.Errorf("Expected flushed 206 without body, got %d %v %q", recorder.Code, recorder.Flushed, recorder.Body.String())
+	}
+	if recorder.Header().Get("Content-Length") != "" {
+		t.Errorf("Expected no Content-Length after flushing, got %q", recorder.Header().Get("Content-Length"))
+	}
+}
+
+func TestOptionsListsMethodsRegisteredForPath(t *testing.T) {
+	handler, details := CreateMockHandler()
+	router := NewRouter().Route(GET, MOCK_PATH, handler).Route(PATCH, MOCK_PATH, handler).Route(POST, ROOT_PATH, handler)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(OPTIONS, MOCK_PATH))
+	if recorder.Code != 204 || recorder.Header().Get("Allow") != "GET, PATCH, HEAD, OPTIONS" {
+		t.Errorf("Expected 204 with Allow GET, PATCH, HEAD, OPTIONS, got %d %q", recorder.Code, recorder.Header().Get("Allow"))
+	}
+	if details.HandlerCallCount != 0 {
+		t.Errorf("Expected no handler to be called, got %d calls", details.HandlerCallCount)
+	}
+}
+
+func TestOptionsIsAnsweredForOverlappingPaths(t *testing.T) {
+	router := NewRouter().Route(GET, "/a/{x}/", listUsers).Route(POST, "/{y}/b/", listUsers).Route(GET, "/files/{path...}", listUsers)
+	cases := map[string]string{
+		"/a/1/":       "GET, HEAD, OPTIONS",
+		"/1/b/":       "POST, OPTIONS",
+		"/a/b/":       "GET, POST, HEAD, OPTIONS",
+		"/files/a/b":  "GET, HEAD, OPTIONS",
+		"/files/%2F/": "GET, HEAD, OPTIONS",
+	}
+	for path, expected := range cases {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, CreateMockHTTPRequest(OPTIONS, path))
+		if recorder.Code != 204 || recorder.Header().Get("Allow") != expected {
+			t.Errorf("Expected OPTIONS %s to give 204 with Allow %s, got %d %q", path, expected, recorder.Code, recorder.Header().Get("Allow"))
+		}
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(OPTIONS, "/b/"))
+	if recorder.Code != 404 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 404, recorder.Code)
+	}
+}
+
+func TestOptionsFollowsTrailingSlashPolicy(t *testing.T) {
+	router := NewRouter().Route(GET, MOCK_PATH, listUsers).TrailingSlash(RedirectSlash)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(OPTIONS, strings.TrimSuffix(MOCK_PATH, "/")))
+	if recorder.Code != 308 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 308, recorder.Code)
+	}
+	router.TrailingSlash(TolerateSlash)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(OPTIONS, strings.TrimSuffix(MOCK_PATH, "/")))
+	if recorder.Code != 204 || recorder.Header().Get("Allow") != "GET, HEAD, OPTIONS" {
+		t.Errorf("Expected 204 with Allow GET, HEAD, OPTIONS, got %d %q", recorder.Code, recorder.Header().Get("Allow"))
+	}
+}
+
+func TestExplicitOptionsRouteReceivesAllowHeader(t *testing.T) {
+	handler, _ := CreateMockHandler()
+	router := NewRouter().Route(DELETE, MOCK_PATH, handler)
+	router.Route(OPTIONS, MOCK_PATH, func(Request) (Response, error) {
+		return RawResponse{StatusCode: 200, Body: []byte("custom")}, nil
+	})
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(OPTIONS, MOCK_PATH))
+	if recorder.Body.String() != "custom" || recorder.Header().Get("Allow") != "DELETE, OPTIONS" {
+		t.Errorf("Expected custom body with Allow DELETE, OPTIONS, got %q %q", recorder.Body.String(), recorder.Header().Get("Allow"))
+	}
+}
+
+func TestDuplicateOptionsRoutePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error(PANIC_EXPECTED_ERROR)
+		}
+	}()
+	handler, _ := CreateMockHandler()
+	NewRouter().Route(OPTIONS, MOCK_PATH, handler).Route(OPTIONS, MOCK_PATH, handler)
+}
+
+func TestOptionsWithoutMatcherUsesRegistrations(t *testing.T) {
+	handler, _ := CreateMockHandler()
+	router := &Router{multiplexer: MockMultiplexer{http.NewServeMux()}, paths: map[string]*pathRoutes{}}
+	router.Route(GET, MOCK_PATH, handler).Route(PUT, MOCK_PATH, handler)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(OPTIONS, MOCK_PATH))
+	if recorder.Header().Get("Allow") != "GET, PUT, HEAD, OPTIONS" {
+		t.Errorf(EXPECTED_STRING_ERROR, "GET, PUT, HEAD, OPTIONS", recorder.Header().Get("Allow"))
+	}
+}
+
+func TestRouterWithoutMatcherServesWithMultiplexer(t *testing.T) {
+	handler, details := CreateMockHandler()
+	router := &Router{multiplexer: MockMultiplexer{http.NewServeMux()}, paths: map[string]*pathRoutes{}}
+	router.Route(GET, MOCK_PATH, handler)
+	router.ServeHTTP(httptest.NewRecorder(), CreateMockHTTPRequest(GET, MOCK_PATH))
+	if details.HandlerCallCount != 1 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 1, details.HandlerCallCount)
+	}
+}
+
+// MockMultiplexer hides the matcher implementation of the multiplexer it wraps.
+type MockMultiplexer struct {
+	multiplexer *http.ServeMux
+}
+
+func (mock MockMultiplexer) Handle(pattern string, handler http.Handler) {
+	mock.multiplexer.Handle(pattern, handler)
+}
+
+func (mock MockMultiplexer) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	mock.multiplexer.ServeHTTP(writer, request)
+}
+
+func CreateRecordingMiddleware(name string, calls *[]string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			*calls = append(*calls, name)
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
+
+func TestUseAppliesMiddlewareInOrder(t *testing.T) {
+	var calls []string
+	router := NewRouter().Route(GET, MOCK_PATH, func(Request) (Response, error) {
+		calls = append(calls, "handler")
+		return NoContent{}, nil
+	})
+	router.Use(CreateRecordingMiddleware("first", &calls)).Use(CreateRecordingMiddleware("second", &calls))
+	router.ServeHTTP(httptest.NewRecorder(), CreateMockHTTPRequest(GET, MOCK_PATH))
+	if strings.Join(calls, ",") != "first,second,handler" {
+		t.Errorf(EXPECTED_STRING_ERROR, "first,second,handler", strings.Join(calls, ","))
+	}
+}
+
+func TestUseAppliesToLinkedRoutersAndUnmatchedRequests(t *testing.T) {
+	var calls []string
+	handler, details := CreateMockHandler()
+	inner := NewRouter().Route(GET, MOCK_PATH, handler).Use(CreateRecordingMiddleware("inner", &calls))
+	router := NewRouter().Link(MOCK_LINK, inner).Use(CreateRecordingMiddleware("outer", &calls))
+	router.ServeHTTP(httptest.NewRecorder(), CreateMockHTTPRequest(GET, MOCK_LINKED_PATH))
+	if strings.Join(calls, ",") != "outer,inner" || details.HandlerCallCount != 1 {
+		t.Errorf("Expected outer,inner before the handler, got %v", calls)
+	}
+	calls = nil
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(GET, "/missing/"))
+	if strings.Join(calls, ",") != "outer" || recorder.Code != 404 {
+		t.Errorf("Expected outer middleware before 404, got %v %d", calls, recorder.Code)
+	}
+}
+
+func TestGroupScopesMiddlewareToPrefix(t *testing.T) {
+	var calls []string
+	router := NewRouter()
+	health, healthDetails := CreateMockHandler()
+	users, usersDetails := CreateMockHandler()
+	router.Route(GET, "/health/", health)
+	router.Group("/admin/", CreateRecordingMiddleware("auth", &calls), CreateRecordingMiddleware("audit", &calls)).
+		Route(GET, "/users/", users)
+
+	router.ServeHTTP(httptest.NewRecorder(), CreateMockHTTPRequest(GET, "/health/"))
+	if len(calls) != 0 || healthDetails.HandlerCallCount != 1 {
+		t.Errorf("Expected no middleware for /health/, got %v", calls)
+	}
+	router.ServeHTTP(httptest.NewRecorder(), CreateMockHTTPRequest(GET, "/admin/users/"))
+	if strings.Join(calls, ",") != "auth,audit" || usersDetails.HandlerCallCount != 1 {
+		t.Errorf("Expected auth,audit for /admin/users/, got %v", calls)
+	}
+	if routes := router.Routes(); len(routes) != 2 || routes[1].Path != "/admin/users/" {
+		t.Errorf("Expected group routes to be listed under the prefix, got %+v", routes)
+	}
+}
+
+func TestMiddlewareCanShortCircuitGroup(t *testing.T) {
+	deny := func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			Unauthorized{Challenge: "Bearer"}.Write(writer)
+		})
+	}
+	handler, details := CreateMockHandler()
+	router := NewRouter()
+	router.Group("/admin/", deny).Route(GET, "/users/", handler)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(GET, "/admin/users/"))
+	if recorder.Code != 401 || details.HandlerCallCount != 0 {
+		t.Errorf("Expected 401 without calling the handler, got %d and %d calls", recorder.Code, details.HandlerCallCount)
+	}
+}
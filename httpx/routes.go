@@ -0,0 +1,111 @@
+package httpx
+
+import (
+	"maps"
+	"net/http"
+	"reflect"
+	"runtime"
+	"slices"
+)
+
+// RouteInfo describes a route registered on a Router.
+type RouteInfo struct {
+	// Name identifies the route to Router.URL, if it was given one with Name.
+	Name   string
+	Method Method
+	// Path is the path of the route, including the paths at which its router is linked.
+	Path string
+	// Handler is the name of the handler function, such as "example.com/app/users.List".
+	Handler string
+	// Host, Headers and ContentTypes are the conditions set with Host, Header and ContentType, including
+	// those of the routers the route is linked to.
+	Host         string
+	Headers      http.Header
+	ContentTypes []string
+	// Metadata holds the values attached to the route with WithMetadata.
+	Metadata map[string]any
+}
+
+// RouteOption attaches information to a route when it is registered.
+type RouteOption func(*RouteInfo)
+
+// WithMetadata attaches a value to the route under the given key, such as a summary or a list of tags
+// for documentation generators.
+func WithMetadata(key string, value any) RouteOption {
+	return func(info *RouteInfo) {
+		if info.Metadata == nil {
+			info.Metadata = map[string]any{}
+		}
+		info.Metadata[key] = value
+	}
+}
+
+// Name names the route, so that its URL can be built with Router.URL.
+// Names must be unique within a router and the routers linked to it.
+func Name(name string) RouteOption {
+	return func(info *RouteInfo) {
+		info.Name = name
+	}
+}
+
+// routeEntry is a route or, if linked is set, a router linked at the path of info.
+type routeEntry struct {
+	info   RouteInfo
+	linked *Router
+}
+
+func newRouteInfo(method Method, path string, handler any, options []RouteOption) RouteInfo {
+	info := RouteInfo{Method: method, Path: path, Handler: handlerName(handler)}
+	for _, option := range options {
+		option(&info)
+	}
+	return info
+}
+
+// handlerName returns the name of the function handler.
+func handlerName(handler any) string {
+	value := reflect.ValueOf(handler)
+	if value.Kind() != reflect.Func {
+		return ""
+	}
+	if function := runtime.FuncForPC(value.Pointer()); function != nil {
+		return function.Name()
+	}
+	return ""
+}
+
+// Routes returns the routes registered on the router and on the routers linked to it, in the order of registration.
+// The paths of the routes of linked routers include the paths at which they are linked.
+// The HEAD and OPTIONS routes answered automatically are not listed.
+func (router *Router) Routes() []RouteInfo {
+	var routes []RouteInfo
+	for _, entry := range router.entries {
+		if entry.linked == nil {
+			info := entry.info
+			info.Metadata = maps.Clone(info.Metadata)
+			info.Headers = info.Headers.Clone()
+			info.ContentTypes = slices.Clone(info.ContentTypes)
+			routes = append(routes, info)
+			continue
+		}
+		prefix := entry.info.Path[:len(entry.info.Path)-1]
+		for _, info := range entry.linked.Routes() {
+			info.Path = prefix + info.Path
+			if info.Host == "" {
+				info.Host = entry.info.Host
+			}
+			if entry.info.Headers != nil {
+				headers := entry.info.Headers.Clone()
+				for key, values := range info.Headers {
+					headers[key] = append(headers[key], values...)
+				}
+				info.Headers = headers
+			}
+			if info.ContentTypes == nil {
+				info.ContentTypes = slices.Clone(entry.info.ContentTypes)
+			}
+			routes = append(routes, info)
+		}
+	}
+	return routes
+}
@@ -0,0 +1,77 @@
+package httpx
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func listUsers(Request) (Response, error) {
+	return NoContent{}, nil
+}
+
+func TestRoutesListsRegisteredRoutesInOrder(t *testing.T) {
+	router := NewRouter().
+		Route(GET, "/users/", listUsers, WithMetadata("summary", "List users")).
+		Route(POST, "/users/", listUsers)
+	routes := router.Routes()
+	if len(routes) != 2 {
+		t.Fatalf(EXPECTED_DIGIT_ERROR, 2, len(routes))
+	}
+	if routes[0].Method != GET || routes[0].Path != "/users/" || routes[1].Method != POST {
+		t.Errorf("Expected GET and POST /users/, got %+v", routes)
+	}
+	if routes[0].Handler != "microx/httpx.listUsers" {
+		t.Errorf(EXPECTED_STRING_ERROR, "microx/httpx.listUsers", routes[0].Handler)
+	}
+	if routes[0].Metadata["summary"] != "List users" || routes[1].Metadata != nil {
+		t.Errorf("Expected metadata on the first route only, got %v and %v", routes[0].Metadata, routes[1].Metadata)
+	}
+}
+
+func TestRoutesNamesTypedHandlersByTheirAdapter(t *testing.T) {
+	router := NewRouter().
+		Route(GET, "/users/{id}/", Typed(MockTypedHandler)).
+		Route(POST, "/users/{id}/", listUsers)
+	routes := router.Routes()
+	if !strings.HasPrefix(routes[0].Handler, "microx/httpx.Typed[") {
+		t.Errorf("Expected a handler returned by Typed to be named after it, got %s", routes[0].Handler)
+	}
+	if routes[1].Handler != "microx/httpx.listUsers" {
+		t.Errorf(EXPECTED_STRING_ERROR, "microx/httpx.listUsers", routes[1].Handler)
+	}
+}
+
+func TestRoutesIncludesLinkedAndMergedRouters(t *testing.T) {
+	users := NewRouter().Route(GET, "/{id}/", listUsers)
+	v1 := NewRouter().Link("/users/", users).Route(GET, "/health/", listUsers)
+	admin := NewRouter().Route(DELETE, "/cache/", listUsers)
+	router := NewRouter().Link("/v1/", v1).Merge(admin)
+	var paths []string
+	for _, route := range router.Routes() {
+		paths = append(paths, string(route.Method)+" "+route.Path)
+	}
+	expected := "GET /v1/users/{id}/, GET /v1/health/, DELETE /cache/"
+	if strings.Join(paths, ", ") != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, strings.Join(paths, ", "))
+	}
+}
+
+func TestRoutesIncludesStaticAndWebSocketRoutes(t *testing.T) {
+	router := NewRouter().Static("/assets/", fstest.MapFS{}).WebSocket("/ws/", echo, WithMetadata("protocol", "chat"))
+	routes := router.Routes()
+	if len(routes) != 2 || routes[0].Path != "/assets/{file...}" || routes[0].Handler != "Static" {
+		t.Fatalf("Expected static route, got %+v", routes)
+	}
+	if routes[1].Path != "/ws/" || routes[1].Handler != "microx/httpx.echo" || routes[1].Metadata["protocol"] != "chat" {
+		t.Errorf("Expected websocket route, got %+v", routes[1])
+	}
+}
+
+func TestRoutesReturnsCopiesOfMetadata(t *testing.T) {
+	router := NewRouter().Route(GET, MOCK_PATH, listUsers, WithMetadata("tag", "a"))
+	router.Routes()[0].Metadata["tag"] = "b"
+	if router.Routes()[0].Metadata["tag"] != "a" {
+		t.Errorf(EXPECTED_STRING_ERROR, "a", router.Routes()[0].Metadata["tag"])
+	}
+}
@@ -2,6 +2,7 @@ package httpx
 
 import (
 	"context"
+	"net"
 	"net/http"
 )
 
@@ -14,7 +15,9 @@ type Server struct {
 	middleware []Middleware
 	// router is the router used by the server.
 	router *Router
-	Closed *bool
+	// settings is the configuration made available to responses through the request context.
+	settings settings
+	Closed   *bool
 }
 
 // NewServer creates a new HTTP server listening on the given address.
@@ -23,7 +26,10 @@ func NewServer(address string) *Server {
 	router := NewRouter()
 	middleware := []Middleware{}
 	httpServer := &http.Server{Addr: address}
-	server := &Server{server: httpServer, middleware: middleware, router: router}
+	server := &Server{server: httpServer, middleware: middleware, router: router, settings: defaultSettings}
+	httpServer.BaseContext = func(net.Listener) context.Context {
+		return withSettings(context.Background(), &server.settings)
+	}
 	return server
 }
 
@@ -42,6 +48,54 @@ func (server *Server) WithMiddleware(middleware Middleware) *Server {
 	return server
 }
 
+// WithProblemDetails makes error responses render as RFC 9457 application/problem+json.
+// This applies to ErrorResponse and the responses built on it, such as InternalServerError, BadRequest and ServiceUnavailable.
+func (server *Server) WithProblemDetails() *Server {
+	server.settings.problemDetails = true
+	return server
+}
+
+// MapError registers an ErrorMapper for errors returned by any handler of the server.
+// Mappers are consulted in the order in which they were registered, after those of the routers.
+func (server *Server) MapError(mapper ErrorMapper) *Server {
+	server.settings.errorMappers = append(server.settings.errorMappers, mapper)
+	return server
+}
+
+// WithPanicReporter sets the PanicReporter called with panics recovered from handlers.
+// By default, panics are logged to slog.Default.
+func (server *Server) WithPanicReporter(reporter PanicReporter) *Server {
+	server.settings.panicReporter = reporter
+	return server
+}
+
+// WithAbortHandlerPropagation makes handlers re-panic http.ErrAbortHandler rather than recovering from it,
+// so that the response is aborted as it would be by the standard library.
+func (server *Server) WithAbortHandlerPropagation() *Server {
+	server.settings.propagateAbortHandler = true
+	return server
+}
+
+// WithEncoder makes an Encoder available to content negotiation for ObjectResponses, which only use JSON by default.
+// It replaces any encoder for the same media type, and is otherwise preferred after the existing encoders.
+// Clients choose among them, so encoders must only write what bodies are meant to expose: XMLEncoder,
+// CBOREncoder and MessagePackEncoder do not call json.Marshaler, and XMLEncoder ignores json tags.
+func (server *Server) WithEncoder(encoder Encoder) *Server {
+	encoders := make([]Encoder, 0, len(server.settings.encoders)+1)
+	replaced := false
+	for _, existing := range server.settings.encoders {
+		if sameMediaType(existing.MediaType(), encoder.MediaType()) {
+			existing, replaced = encoder, true
+		}
+		encoders = append(encoders, existing)
+	}
+	if !replaced {
+		encoders = append(encoders, encoder)
+	}
+	server.settings.encoders = encoders
+	return server
+}
+
 // Shutdown gracefully shuts down the server without interrupting any active connections.
 // It blocks until all connections are closed.
 //
@@ -1,8 +1,12 @@
 package httpx
 
 import (
+	"io"
+	"net"
 	"net/http"
+	"reflect"
 	"testing"
+	"time"
 )
 
 const EOF_ERROR = "Get \"http://localhost:8000/\": EOF"
@@ -73,7 +77,7 @@ func TestMiddlewareIsApplied(t *testing.T) {
 		}, nil
 	})
 	server.WithRouter(router)
-	go server.Start()
+	startMockServer(t, server)
 	defer server.Shutdown()
 	_, err := http.Get(REQUEST_ADDRESS)
 	if err != nil {
@@ -94,7 +98,7 @@ func TestShutdownClosesGracefully(t *testing.T) {
 	requests := make(chan bool, 1)
 	unblock := make(chan bool, 1)
 	server := createServer(requests, unblock)
-	go server.Start()
+	startMockServer(t, server)
 	errors := make(chan error, 1)
 	go func() {
 		_, err := http.Get(REQUEST_ADDRESS)
@@ -115,7 +119,7 @@ func TestForceShutdownClosesAllConnections(t *testing.T) {
 	requests := make(chan bool, 1)
 	unblock := make(chan bool, 1)
 	server := createServer(requests, unblock)
-	go server.Start()
+	startMockServer(t, server)
 	errors := make(chan error, 1)
 	go func() {
 		_, err := http.Get(REQUEST_ADDRESS)
@@ -147,3 +151,96 @@ func createServer(requests chan bool, unblock chan bool) *Server {
 	server.WithRouter(router)
 	return server
 }
+
+func TestNewServerDoesNotUseProblemDetails(t *testing.T) {
+	server := NewServer("")
+	if settingsFrom(server.server.BaseContext(nil)).problemDetails {
+		t.Error("Expected problem details to be disabled")
+	}
+}
+
+func TestWithProblemDetailsEnablesProblemDetailsInBaseContext(t *testing.T) {
+	server := NewServer("").WithProblemDetails()
+	if !settingsFrom(server.server.BaseContext(nil)).problemDetails {
+		t.Error("Expected problem details to be enabled")
+	}
+}
+
+// startMockServer starts the server in the background and waits until it accepts connections.
+func startMockServer(t *testing.T, server *Server) {
+	t.Helper()
+	go server.Start()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		if connection, err := net.Dial("tcp", ADDRESS); err == nil {
+			connection.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Server did not accept connections on %s", ADDRESS)
+}
+
+func TestResponseHeadersReachClient(t *testing.T) {
+	server := NewServer(ADDRESS)
+	router := NewRouter()
+	router.Route(http.MethodGet, "/", func(r Request) (Response, error) {
+		return ObjectResponse{
+			StatusCode: 201,
+			Headers: http.Header{
+				"X-Custom":   {"value"},
+				"set-cookie": {"a=1", "b=2"},
+			},
+			Body: map[string]string{"#": MOCK_BODY},
+		}, nil
+	})
+	server.WithRouter(router)
+	startMockServer(t, server)
+	defer server.Shutdown()
+	response, err := http.Get(REQUEST_ADDRESS)
+	if err != nil {
+		t.Fatalf("Failed to make request, got %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != 201 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 201, response.StatusCode)
+	}
+	if response.Header.Get("X-Custom") != "value" {
+		t.Errorf(EXPECTED_STRING_ERROR, "value", response.Header.Get("X-Custom"))
+	}
+	if cookies := response.Header.Values("Set-Cookie"); !reflect.DeepEqual(cookies, []string{"a=1", "b=2"}) {
+		t.Errorf("Expected both cookies, got %v", cookies)
+	}
+	if response.Header.Get(CONTENT_TYPE_HEADER_KEY) != CONTENT_TYPE_JSON {
+		t.Errorf(EXPECTED_STRING_ERROR, CONTENT_TYPE_JSON, response.Header.Get(CONTENT_TYPE_HEADER_KEY))
+	}
+	body, _ := io.ReadAll(response.Body)
+	if string(body) != MOCK_BODY_JSON {
+		t.Errorf(EXPECTED_STRING_ERROR, MOCK_BODY_JSON, string(body))
+	}
+}
+
+func TestRawResponseHeadersReachClient(t *testing.T) {
+	server := NewServer(ADDRESS)
+	router := NewRouter()
+	router.Route(http.MethodGet, "/", func(r Request) (Response, error) {
+		return RawResponse{
+			StatusCode: 200,
+			Headers:    http.Header{CONTENT_TYPE_HEADER_KEY: {CONTENT_TYPE_TEXT}, "Vary": {"Accept", "Origin"}},
+			Body:       []byte(MOCK_BODY),
+		}, nil
+	})
+	server.WithRouter(router)
+	startMockServer(t, server)
+	defer server.Shutdown()
+	response, err := http.Get(REQUEST_ADDRESS)
+	if err != nil {
+		t.Fatalf("Failed to make request, got %v", err)
+	}
+	defer response.Body.Close()
+	if response.Header.Get(CONTENT_TYPE_HEADER_KEY) != CONTENT_TYPE_TEXT {
+		t.Errorf(EXPECTED_STRING_ERROR, CONTENT_TYPE_TEXT, response.Header.Get(CONTENT_TYPE_HEADER_KEY))
+	}
+	if vary := response.Header.Values("Vary"); !reflect.DeepEqual(vary, []string{"Accept", "Origin"}) {
+		t.Errorf("Expected both Vary values, got %v", vary)
+	}
+}
@@ -0,0 +1,84 @@
+package httpx
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// TrailingSlashPolicy decides how a Router answers a request whose path lacks the trailing slash of a route,
+// such as a request for /users when the route is registered for /users/.
+type TrailingSlashPolicy int
+
+const (
+	// StrictSlash answers such requests as not found, so that each route matches a single path. It is the default.
+	StrictSlash TrailingSlashPolicy = iota + 1
+	// RedirectSlash redirects such requests to the path with the trailing slash, with 301 Moved Permanently
+	// for GET and HEAD requests and 308 Permanent Redirect for other methods, which must not change the method.
+	RedirectSlash
+	// TolerateSlash serves such requests as if their path had the trailing slash.
+	TolerateSlash
+)
+
+// TrailingSlash sets the policy for requests whose path lacks the trailing slash of a route of this router.
+// It also applies to any routers linked to it that do not set their own, and is only applied by multiplexers
+// that can report the pattern matching a request, such as http.ServeMux.
+func (router *Router) TrailingSlash(policy TrailingSlashPolicy) *Router {
+	router.trailingSlash = policy
+	return router
+}
+
+// exactPattern returns the pattern that matches path only, if path ends in a "/".
+func exactPattern(path string) string {
+	if strings.HasSuffix(path, "/") {
+		return path + "{$}"
+	}
+	return path
+}
+
+// redirectsToSlash reports whether pattern matches path, an escaped request path, only once a trailing slash is
+// added, in which case http.ServeMux redirects the request rather than serving it.
+func redirectsToSlash(pattern, path string) bool {
+	if strings.HasSuffix(path, "/") {
+		return false
+	}
+	pattern = pattern[strings.Index(pattern, "/"):]
+	return strings.Count(path, "/") < strings.Count(pattern[:strings.LastIndex(pattern, "/")+1], "/")
+}
+
+// serveWithoutSlash answers a request whose path lacks the trailing slash of a route, according to the
+// TrailingSlashPolicy of the innermost router that sets one. Tolerated requests are served by next, with the slash.
+func serveWithoutSlash(next http.Handler, writer http.ResponseWriter, request *http.Request) {
+	policy := StrictSlash
+	for scope := routerScopeFrom(request.Context()); scope != nil; scope = scope.parent {
+		if scope.router.trailingSlash != 0 {
+			policy = scope.router.trailingSlash
+			break
+		}
+	}
+	switch policy {
+	case RedirectSlash:
+		location := path.Base(request.URL.EscapedPath()) + "/"
+		if request.URL.RawQuery != "" {
+			location += "?" + request.URL.RawQuery
+		}
+		var response Response = PermanentRedirect{location}
+		if request.Method == string(GET) || request.Method == string(HEAD) {
+			response = MovedPermanently{location}
+		}
+		adapt(func(Request) (Response, error) { return response, nil }).ServeHTTP(writer, request)
+	case TolerateSlash:
+		tolerated := new(http.Request)
+		*tolerated = *request
+		tolerated.URL = new(url.URL)
+		*tolerated.URL = *request.URL
+		tolerated.URL.Path += "/"
+		if tolerated.URL.RawPath != "" {
+			tolerated.URL.RawPath += "/"
+		}
+		next.ServeHTTP(writer, tolerated)
+	default:
+		serveNotFound(writer, request)
+	}
+}
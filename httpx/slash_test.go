@@ -0,0 +1,106 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRoutesMatchTheirPathExactly(t *testing.T) {
+	handler, details := CreateMockHandler()
+	router := NewRouter().Route(GET, "/users/", handler)
+	for _, path := range []string{"/users/anything/", "/users/anything", "/users"} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, CreateMockHTTPRequest(GET, path))
+		if recorder.Code != 404 {
+			t.Errorf("Expected 404 for %s, got %d", path, recorder.Code)
+		}
+	}
+	if details.HandlerCallCount != 0 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 0, details.HandlerCallCount)
+	}
+}
+
+func TestStrictSlashDoesNotReportAllowedMethods(t *testing.T) {
+	handler, _ := CreateMockHandler()
+	router := NewRouter().Route(POST, "/items/", handler)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(GET, "/items"))
+	if recorder.Code != 404 || recorder.Header().Get("Allow") != "" {
+		t.Errorf("Expected 404 without Allow, got %d %q", recorder.Code, recorder.Header().Get("Allow"))
+	}
+}
+
+func TestRedirectSlashRedirectsToCanonicalPath(t *testing.T) {
+	handler, _ := CreateMockHandler()
+	router := NewRouter().Route(GET, "/users/", handler).Route(POST, "/users/", handler).TrailingSlash(RedirectSlash)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/users?page=2", nil))
+	if recorder.Code != 301 || recorder.Header().Get("Location") != "users/?page=2" {
+		t.Errorf("Expected 301 to users/?page=2, got %d %q", recorder.Code, recorder.Header().Get("Location"))
+	}
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(POST, "/users"))
+	if recorder.Code != 308 || recorder.Header().Get("Location") != "users/" {
+		t.Errorf("Expected 308 to users/, got %d %q", recorder.Code, recorder.Header().Get("Location"))
+	}
+}
+
+func TestRedirectSlashKeepsEncodedSlashes(t *testing.T) {
+	for _, multiplexer := range []Multiplexer{http.NewServeMux(), NewRadixMux()} {
+		router := NewRouter().WithMultiplexer(multiplexer).Route(GET, "/users/{id}/", listUsers).TrailingSlash(RedirectSlash)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest("GET", "/users/a%2Fb", nil))
+		if recorder.Code != 301 || recorder.Header().Get("Location") != "a%2Fb/" {
+			t.Errorf("Expected %T to redirect to a%%2Fb/ with 301, got %d %q", multiplexer, recorder.Code, recorder.Header().Get("Location"))
+		}
+	}
+}
+
+func TestTolerateSlashServesPathWithoutSlash(t *testing.T) {
+	var values []string
+	router := NewRouter().Route(GET, "/users/{id}/", recordParam("id", &values)).TrailingSlash(TolerateSlash)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, CreateMockHTTPRequest(GET, "/users/42"))
+	if recorder.Code != 204 || len(values) != 1 || values[0] != "id=42" {
+		t.Errorf("Expected id=42 to be served, got %d %v", recorder.Code, values)
+	}
+}
+
+func TestLinkedRoutersInheritTrailingSlashPolicy(t *testing.T) {
+	handler, details := CreateMockHandler()
+	inner := NewRouter().Route(GET, MOCK_PATH, handler)
+	router := NewRouter().Link(MOCK_LINK, inner).TrailingSlash(RedirectSlash)
+	for path, location := range map[string]string{"/link/path": "path/", "/link": "link/"} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, CreateMockHTTPRequest(GET, path))
+		if recorder.Code != 301 || recorder.Header().Get("Location") != location {
+			t.Errorf("Expected 301 to %s, got %d %q", location, recorder.Code, recorder.Header().Get("Location"))
+		}
+	}
+	inner.TrailingSlash(TolerateSlash)
+	router.ServeHTTP(httptest.NewRecorder(), CreateMockHTTPRequest(GET, "/link/path"))
+	if details.HandlerCallCount != 1 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 1, details.HandlerCallCount)
+	}
+}
+
+func TestRedirectsToSlash(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		expected      bool
+	}{
+		{"GET /users/{$}", "/users", true},
+		{"GET /users/{id}/{$}", "/users/42", true},
+		{"/link/", "/link", true},
+		{"/link/", "/link/path", false},
+		{"GET /files/{path...}", "/files", true},
+		{"GET /files/{path...}", "/files/docs", false},
+		{"GET /users/{$}", "/users/", false},
+	}
+	for _, c := range cases {
+		if actual := redirectsToSlash(c.pattern, c.path); actual != c.expected {
+			t.Errorf("Expected %s for %s to be %t, got %t", c.pattern, c.path, c.expected, actual)
+		}
+	}
+}
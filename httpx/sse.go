@@ -0,0 +1,137 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LastEventIDHeader is the header in which reconnecting EventSource clients send the ID of the last event they received.
+const LastEventIDHeader = "Last-Event-ID"
+
+// errLineBreak is returned when a single line field of an Event contains a line break.
+var errLineBreak = errors.New("sse: event id and name must not contain line breaks")
+
+// Event is a single Server-Sent Event.
+type Event struct {
+	// ID is sent to the client, which reports the last ID it received in the Last-Event-ID header when it reconnects.
+	ID string
+	// Name is the event type, dispatched to listeners of that type rather than to onmessage.
+	Name string
+	// Data is the payload of the event. Strings and byte slices are sent as is, other values as JSON.
+	// Events without data are not dispatched by browsers.
+	Data any
+	// Retry, if positive, tells the client how long to wait before reconnecting.
+	Retry time.Duration
+}
+
+// SSEResponse writes Events as a text/event-stream of Server-Sent Events, flushing each event to the client.
+// Events are written until the channel is closed or the client disconnects.
+// Clients resume from the Last-Event-ID header of the request; see Request.LastEventID and Broker.Response.
+type SSEResponse struct {
+	StatusCode int
+	Headers    http.Header
+	Events     <-chan Event
+	// Retry, if positive, is sent before the first event as the reconnection delay of the client.
+	Retry time.Duration
+	// Heartbeat, if positive, is the idle period after which a comment is sent to keep the connection open
+	// through proxies and to detect clients that have gone away.
+	Heartbeat time.Duration
+}
+
+func (response SSEResponse) Write(writer ResponseWriter) error {
+	ctx := requestContext(writer)
+	writeHeader(writer, response.StatusCode, response.Headers, http.Header{
+		"Content-Type":  {"text/event-stream"},
+		"Cache-Control": {"no-cache"},
+	})
+	if response.Retry > 0 {
+		if _, err := writer.Write([]byte("retry: " + formatRetry(response.Retry) + "\n\n")); err != nil {
+			return err
+		}
+	}
+	flush(writer)
+	var heartbeat <-chan time.Time
+	resetHeartbeat := func() {}
+	if response.Heartbeat > 0 {
+		ticker := time.NewTicker(response.Heartbeat)
+		defer ticker.Stop()
+		heartbeat, resetHeartbeat = ticker.C, func() { ticker.Reset(response.Heartbeat) }
+	}
+	return response.writeEvents(ctx, writer, heartbeat, resetHeartbeat)
+}
+
+// writeEvents writes events until the channel is closed, writing a comment whenever heartbeat fires.
+// resetHeartbeat is called after each event, so that heartbeats are only sent while the stream is idle.
+func (response SSEResponse) writeEvents(ctx context.Context, writer ResponseWriter, heartbeat <-chan time.Time, resetHeartbeat func()) error {
+	for {
+		var encoded []byte
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-heartbeat:
+			encoded = []byte(": heartbeat\n\n")
+		case event, ok := <-response.Events:
+			if !ok {
+				return nil
+			}
+			var err error
+			if encoded, err = event.encode(); err != nil {
+				return err
+			}
+			resetHeartbeat()
+		}
+		if _, err := writer.Write(encoded); err != nil {
+			return err
+		}
+		flush(writer)
+	}
+}
+
+// encode returns the event in the text/event-stream format, terminated by a blank line.
+func (event Event) encode() ([]byte, error) {
+	if strings.ContainsAny(event.ID, "\r\n") || strings.ContainsAny(event.Name, "\r\n") {
+		return nil, errLineBreak
+	}
+	var buffer bytes.Buffer
+	if event.ID != "" {
+		buffer.WriteString("id: " + event.ID + "\n")
+	}
+	if event.Name != "" {
+		buffer.WriteString("event: " + event.Name + "\n")
+	}
+	if event.Retry > 0 {
+		buffer.WriteString("retry: " + formatRetry(event.Retry) + "\n")
+	}
+	if event.Data != nil {
+		var data string
+		switch value := event.Data.(type) {
+		case string:
+			data = value
+		case []byte:
+			data = string(value)
+		default:
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			data = string(encoded)
+		}
+		data = strings.ReplaceAll(strings.ReplaceAll(data, "\r\n", "\n"), "\r", "\n")
+		for _, line := range strings.Split(data, "\n") {
+			buffer.WriteString("data: " + line + "\n")
+		}
+	}
+	buffer.WriteByte('\n')
+	return buffer.Bytes(), nil
+}
+
+// formatRetry formats a reconnection delay in milliseconds, as the retry field expects.
+func formatRetry(retry time.Duration) string {
+	return strconv.FormatInt(retry.Milliseconds(), 10)
+}
@@ -0,0 +1,124 @@
+package httpx
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeEvents(response SSEResponse, ctx context.Context) (*httptest.ResponseRecorder, error) {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, MOCK_PATH, nil).WithContext(ctx)
+	err := response.Write(&requestWriter{recorder, request})
+	return recorder, err
+}
+
+func CreateMockEvents(events ...Event) <-chan Event {
+	channel := make(chan Event, len(events))
+	for _, event := range events {
+		channel <- event
+	}
+	close(channel)
+	return channel
+}
+
+func TestSSEResponseWritesEvents(t *testing.T) {
+	events := CreateMockEvents(
+		Event{ID: "1", Name: "status", Data: "up"},
+		Event{Data: map[string]int{"load": 3}},
+		Event{ID: "3", Retry: 2 * time.Second},
+	)
+	recorder, err := writeEvents(SSEResponse{Events: events}, context.Background())
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	expected := "id: 1\nevent: status\ndata: up\n\n" + "data: {\"load\":3}\n\n" + "id: 3\nretry: 2000\n\n"
+	if recorder.Body.String() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, recorder.Body.String())
+	}
+	if recorder.Header().Get(CONTENT_TYPE_HEADER_KEY) != "text/event-stream" {
+		t.Errorf(EXPECTED_STRING_ERROR, "text/event-stream", recorder.Header().Get(CONTENT_TYPE_HEADER_KEY))
+	}
+	if recorder.Header().Get("Cache-Control") != "no-cache" {
+		t.Errorf(EXPECTED_STRING_ERROR, "no-cache", recorder.Header().Get("Cache-Control"))
+	}
+	if !recorder.Flushed {
+		t.Error("Expected response to be flushed")
+	}
+}
+
+func TestSSEResponseSplitsMultilineData(t *testing.T) {
+	recorder, _ := writeEvents(SSEResponse{Events: CreateMockEvents(Event{Data: []byte("a\r\nb\rc\nd")})}, context.Background())
+	expected := "data: a\ndata: b\ndata: c\ndata: d\n\n"
+	if recorder.Body.String() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, recorder.Body.String())
+	}
+}
+
+func TestSSEResponseRejectsLineBreaksInSingleLineFields(t *testing.T) {
+	for _, event := range []Event{{ID: "1\n"}, {Name: "a\rb"}} {
+		recorder, err := writeEvents(SSEResponse{Events: CreateMockEvents(event)}, context.Background())
+		if err != errLineBreak {
+			t.Errorf("Expected errLineBreak, got %v", err)
+		}
+		if recorder.Body.Len() != 0 {
+			t.Errorf("Expected nothing to be written, got %q", recorder.Body.String())
+		}
+	}
+}
+
+func TestSSEResponseReportsEncodingErrors(t *testing.T) {
+	if _, err := writeEvents(SSEResponse{Events: CreateMockEvents(Event{Data: make(chan int)})}, context.Background()); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestSSEResponseWritesRetryHintFirst(t *testing.T) {
+	recorder, _ := writeEvents(SSEResponse{Events: CreateMockEvents(Event{Data: "x"}), Retry: 1500 * time.Millisecond}, context.Background())
+	if !strings.HasPrefix(recorder.Body.String(), "retry: 1500\n\n") {
+		t.Errorf("Expected retry hint first, got %q", recorder.Body.String())
+	}
+}
+
+func TestSSEResponseStopsWhenContextIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := writeEvents(SSEResponse{Events: make(chan Event)}, ctx)
+	if err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestSSEResponseSendsHeartbeatsWhileIdle(t *testing.T) {
+	events := make(chan Event)
+	router := NewRouter()
+	router.Route(GET, "/", func(r Request) (Response, error) {
+		return SSEResponse{Events: events, Heartbeat: 10 * time.Millisecond}, nil
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+	response, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to make request, got %v", err)
+	}
+	defer response.Body.Close()
+	reader := bufio.NewReader(response.Body)
+	line, err := reader.ReadString('\n')
+	if err != nil || line != ": heartbeat\n" {
+		t.Errorf(EXPECTED_STRING_ERROR, ": heartbeat\n", line)
+	}
+	close(events)
+}
+
+func TestLastEventIDReadsHeader(t *testing.T) {
+	request := CreateMockHTTPRequest(GET, MOCK_PATH)
+	request.Header = http.Header{}
+	request.Header.Set(LastEventIDHeader, "42")
+	if id := (*Request)(request).LastEventID(); id != "42" {
+		t.Errorf(EXPECTED_STRING_ERROR, "42", id)
+	}
+}
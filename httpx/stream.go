@@ -0,0 +1,157 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// StreamFormat selects how the items of a StreamResponse are written.
+type StreamFormat int
+
+const (
+	// NDJSON writes each item as a line of JSON, as application/x-ndjson.
+	NDJSON StreamFormat = iota
+	// JSONArray writes the items as the elements of a single JSON array, as application/json.
+	JSONArray
+)
+
+// streamChunkSize is the size of the chunks in which a StreamResponse copies its Body.
+const streamChunkSize = 32 * 1024
+
+// StreamResponse writes its body incrementally, flushing each part to the client as soon as it is written.
+// The body is copied from Body, as application/octet-stream by default, or produced by Items in the given Format.
+// Writing stops once the client disconnects or a write fails, so sources that block should watch the request context.
+type StreamResponse struct {
+	StatusCode int
+	Headers    http.Header
+	// Body is copied to the client as it is read. If set, Items and Format are ignored.
+	// A Body that is an io.Closer is closed once copying ends, or as soon as the request context is cancelled
+	// so that a blocked Read returns.
+	Body io.Reader
+	// Items yields the items to write. It must return once yield returns false.
+	Items  func(yield func(any) bool)
+	Format StreamFormat
+}
+
+// FromChannel adapts a channel to the Items of a StreamResponse.
+// Items are yielded until the channel is closed or ctx is done, which for the request context happens when the
+// client disconnects, so that a producer that stops sending does not hold up the handler.
+func FromChannel[T any](ctx context.Context, channel <-chan T) func(yield func(any) bool) {
+	return func(yield func(any) bool) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case item, ok := <-channel:
+				if !ok || !yield(item) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// FromSeq adapts a typed iterator, such as an iter.Seq, to the Items of a StreamResponse.
+func FromSeq[T any](seq func(yield func(T) bool)) func(yield func(any) bool) {
+	return func(yield func(any) bool) {
+		seq(func(item T) bool {
+			return yield(item)
+		})
+	}
+}
+
+func (response StreamResponse) Write(writer ResponseWriter) error {
+	ctx := requestContext(writer)
+	if response.Body != nil {
+		writeHeader(writer, response.StatusCode, response.Headers, http.Header{"Content-Type": {"application/octet-stream"}})
+		flush(writer)
+		return response.copyBody(ctx, writer)
+	}
+	contentType := "application/x-ndjson"
+	if response.Format == JSONArray {
+		contentType = "application/json"
+	}
+	writeHeader(writer, response.StatusCode, response.Headers, http.Header{"Content-Type": {contentType}})
+	flush(writer)
+	return response.writeItems(ctx, writer)
+}
+
+func (response StreamResponse) copyBody(ctx context.Context, writer ResponseWriter) error {
+	if closer, ok := response.Body.(io.Closer); ok {
+		closeBody := sync.OnceValue(closer.Close)
+		stop := context.AfterFunc(ctx, func() { closeBody() })
+		defer func() {
+			stop()
+			closeBody()
+		}()
+	}
+	buffer := make([]byte, streamChunkSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, readErr := response.Body.Read(buffer)
+		if readErr != nil && readErr != io.EOF && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if n > 0 {
+			if _, err := writer.Write(buffer[:n]); err != nil {
+				return err
+			}
+			flush(writer)
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+func (response StreamResponse) writeItems(ctx context.Context, writer ResponseWriter) error {
+	if response.Items == nil {
+		response.Items = func(func(any) bool) {}
+	}
+	if response.Format == JSONArray {
+		if _, err := writer.Write([]byte("[")); err != nil {
+			return err
+		}
+	}
+	var err error
+	first := true
+	response.Items(func(item any) bool {
+		if err = ctx.Err(); err != nil {
+			return false
+		}
+		var encoded []byte
+		if encoded, err = json.Marshal(item); err != nil {
+			return false
+		}
+		if response.Format == JSONArray && !first {
+			encoded = append([]byte(","), encoded...)
+		} else if response.Format == NDJSON {
+			encoded = append(encoded, '\n')
+		}
+		first = false
+		if _, err = writer.Write(encoded); err != nil {
+			return false
+		}
+		flush(writer)
+		return true
+	})
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return err
+	}
+	if response.Format == JSONArray {
+		_, err = writer.Write([]byte("]"))
+		flush(writer)
+	}
+	return err
+}
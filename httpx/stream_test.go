@@ -0,0 +1,268 @@
+package httpx
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type MockFailingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (MockFailingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+type MockFailingReader struct{}
+
+func (MockFailingReader) Read([]byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+type MockClosingReader struct {
+	io.Reader
+	closed bool
+}
+
+func (reader *MockClosingReader) Close() error {
+	reader.closed = true
+	return nil
+}
+
+func CreateMockItems(items ...any) func(yield func(any) bool) {
+	return func(yield func(any) bool) {
+		for _, item := range items {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+func writeStream(response StreamResponse, ctx context.Context) (*httptest.ResponseRecorder, error) {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, MOCK_PATH, nil).WithContext(ctx)
+	err := response.Write(&requestWriter{recorder, request})
+	return recorder, err
+}
+
+func TestStreamResponseWritesNDJSON(t *testing.T) {
+	recorder, err := writeStream(StreamResponse{Items: CreateMockItems(1, "two", map[string]int{"three": 3})}, context.Background())
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	expected := "1\n\"two\"\n{\"three\":3}\n"
+	if recorder.Body.String() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, recorder.Body.String())
+	}
+	if recorder.Header().Get(CONTENT_TYPE_HEADER_KEY) != "application/x-ndjson" {
+		t.Errorf(EXPECTED_STRING_ERROR, "application/x-ndjson", recorder.Header().Get(CONTENT_TYPE_HEADER_KEY))
+	}
+	if !recorder.Flushed {
+		t.Error("Expected response to be flushed")
+	}
+}
+
+func TestStreamResponseWritesJSONArray(t *testing.T) {
+	recorder, _ := writeStream(StreamResponse{Items: CreateMockItems(1, 2, 3), Format: JSONArray}, context.Background())
+	if recorder.Body.String() != "[1,2,3]" {
+		t.Errorf(EXPECTED_STRING_ERROR, "[1,2,3]", recorder.Body.String())
+	}
+	if recorder.Header().Get(CONTENT_TYPE_HEADER_KEY) != CONTENT_TYPE_JSON {
+		t.Errorf(EXPECTED_STRING_ERROR, CONTENT_TYPE_JSON, recorder.Header().Get(CONTENT_TYPE_HEADER_KEY))
+	}
+}
+
+func TestStreamResponseWritesEmptyJSONArrayWithoutItems(t *testing.T) {
+	recorder, _ := writeStream(StreamResponse{Format: JSONArray}, context.Background())
+	if recorder.Body.String() != "[]" {
+		t.Errorf(EXPECTED_STRING_ERROR, "[]", recorder.Body.String())
+	}
+}
+
+func TestStreamResponseCopiesBody(t *testing.T) {
+	body := strings.Repeat("x", streamChunkSize+1)
+	recorder, err := writeStream(StreamResponse{StatusCode: 200, Body: strings.NewReader(body)}, context.Background())
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if recorder.Body.String() != body {
+		t.Errorf(EXPECTED_DIGIT_ERROR, len(body), recorder.Body.Len())
+	}
+	if recorder.Header().Get(CONTENT_TYPE_HEADER_KEY) != "application/octet-stream" {
+		t.Errorf(EXPECTED_STRING_ERROR, "application/octet-stream", recorder.Header().Get(CONTENT_TYPE_HEADER_KEY))
+	}
+}
+
+func TestStreamResponseReportsReadErrors(t *testing.T) {
+	if _, err := writeStream(StreamResponse{Body: MockFailingReader{}}, context.Background()); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestStreamResponseStopsWhenContextIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	yielded := 0
+	items := func(yield func(any) bool) {
+		for i := 0; i < 10; i++ {
+			yielded++
+			if i == 2 {
+				cancel()
+			}
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	recorder, err := writeStream(StreamResponse{Items: items, Format: JSONArray}, ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if yielded != 3 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 3, yielded)
+	}
+	if recorder.Body.String() != "[0,1" {
+		t.Errorf(EXPECTED_STRING_ERROR, "[0,1", recorder.Body.String())
+	}
+}
+
+func TestStreamResponseStopsCopyingWhenContextIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	recorder, err := writeStream(StreamResponse{Body: strings.NewReader("data")}, ctx)
+	if !errors.Is(err, context.Canceled) || recorder.Body.Len() != 0 {
+		t.Errorf("Expected nothing to be copied, got %v %q", err, recorder.Body.String())
+	}
+}
+
+func TestStreamResponseClosesBody(t *testing.T) {
+	reader, writer := io.Pipe()
+	go writer.Write([]byte("data"))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := writeStream(StreamResponse{Body: reader}, ctx)
+		done <- err
+	}()
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a blocked read to be interrupted when the context is cancelled")
+	}
+	if _, err := writer.Write([]byte("more")); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("Expected the body to be closed, got %v", err)
+	}
+
+	body := &MockClosingReader{Reader: strings.NewReader("data")}
+	if _, err := writeStream(StreamResponse{Body: body}, context.Background()); err != nil || !body.closed {
+		t.Errorf("Expected the body to be closed after copying, got %v %v", err, body.closed)
+	}
+}
+
+func TestStreamResponseStopsOnEncodingError(t *testing.T) {
+	recorder, err := writeStream(StreamResponse{Items: CreateMockItems(1, make(chan int), 3)}, context.Background())
+	if err == nil {
+		t.Error("Expected error, got nil")
+	}
+	if recorder.Body.String() != "1\n" {
+		t.Errorf(EXPECTED_STRING_ERROR, "1\n", recorder.Body.String())
+	}
+}
+
+func TestStreamResponseStopsOnWriteError(t *testing.T) {
+	writer := MockFailingWriter{httptest.NewRecorder()}
+	if err := (StreamResponse{Items: CreateMockItems(1)}).Write(writer); err == nil {
+		t.Error("Expected error, got nil")
+	}
+	if err := (StreamResponse{Items: CreateMockItems(1), Format: JSONArray}).Write(writer); err == nil {
+		t.Error("Expected error, got nil")
+	}
+	if err := (StreamResponse{Body: strings.NewReader("data")}).Write(writer); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestFromChannelYieldsUntilClosed(t *testing.T) {
+	channel := make(chan int, 3)
+	channel <- 1
+	channel <- 2
+	close(channel)
+	recorder, _ := writeStream(StreamResponse{Items: FromChannel(context.Background(), channel)}, context.Background())
+	if recorder.Body.String() != "1\n2\n" {
+		t.Errorf(EXPECTED_STRING_ERROR, "1\n2\n", recorder.Body.String())
+	}
+}
+
+func TestFromChannelStopsWhenYieldReturnsFalse(t *testing.T) {
+	channel := make(chan int, 3)
+	channel <- 1
+	channel <- 2
+	FromChannel(context.Background(), channel)(func(any) bool { return false })
+	if len(channel) != 1 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 1, len(channel))
+	}
+}
+
+func TestFromChannelStopsWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	channel := make(chan int)
+	done := make(chan error)
+	go func() {
+		_, err := writeStream(StreamResponse{Items: FromChannel(ctx, channel)}, ctx)
+		done <- err
+	}()
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected the stream to stop when the context is done")
+	}
+}
+
+func TestFromSeqYieldsTypedItems(t *testing.T) {
+	seq := func(yield func(string) bool) {
+		_ = yield("a") && yield("b")
+	}
+	recorder, _ := writeStream(StreamResponse{Items: FromSeq(seq), Format: JSONArray}, context.Background())
+	if recorder.Body.String() != `["a","b"]` {
+		t.Errorf(EXPECTED_STRING_ERROR, `["a","b"]`, recorder.Body.String())
+	}
+}
+
+func TestStreamResponseFlushesEachItemToClient(t *testing.T) {
+	channel := make(chan int)
+	router := NewRouter()
+	router.Route(GET, "/", func(r Request) (Response, error) {
+		return StreamResponse{Items: FromChannel(r.Context(), channel)}, nil
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+	response, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to make request, got %v", err)
+	}
+	defer response.Body.Close()
+	reader := bufio.NewReader(response.Body)
+	for i := 0; i < 2; i++ {
+		channel <- i
+		line, err := reader.ReadString('\n')
+		if err != nil || line != string(rune('0'+i))+"\n" {
+			t.Errorf("Expected item %d to arrive before the next is produced, got %q %v", i, line, err)
+		}
+	}
+	close(channel)
+}
@@ -0,0 +1,87 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+// Typed adapts a function that operates on decoded values to a Handler.
+// The request body, if any, is decoded as JSON into In, which is then bound and validated as by Bind and Validate;
+// failures are written as a BadRequest or an UnprocessableEntity. Typed panics if In has a malformed validate rule.
+// A returned Out that is a Response is written as is, a nil Out as 204 No Content, and any other Out as an
+// ObjectResponse with status 201 for POST requests and 200 otherwise.
+func Typed[In any, Out any](handler func(context.Context, In) (Out, error)) Handler {
+	inType := reflect.TypeOf((*In)(nil)).Elem()
+	if inType.Kind() == reflect.Pointer {
+		inType = inType.Elem()
+	}
+	if inType.Kind() == reflect.Struct {
+		checkRules(inType)
+	}
+	return func(request Request) (Response, error) {
+		var in In
+		target := any(&in)
+		if inType := reflect.TypeOf(in); inType != nil && inType.Kind() == reflect.Pointer {
+			reflect.ValueOf(&in).Elem().Set(reflect.New(inType.Elem()))
+			target = any(in)
+		}
+		if err := decodeBody(&request, target); err != nil {
+			return BadRequest{err}, nil
+		}
+		if isStructPointer(target) {
+			if err := Bind(request, target); err != nil {
+				return nil, err
+			}
+			if err := Validate(target); err != nil {
+				return nil, err
+			}
+		}
+		out, err := handler(request.Context(), in)
+		if err != nil {
+			return nil, err
+		}
+		if value := reflect.ValueOf(out); !value.IsValid() || (value.Kind() == reflect.Pointer && value.IsNil()) {
+			return NoContent{}, nil
+		}
+		if response, ok := any(out).(Response); ok {
+			return response, nil
+		}
+		statusCode := http.StatusOK
+		if request.Method == string(POST) {
+			statusCode = http.StatusCreated
+		}
+		return ObjectResponse{StatusCode: statusCode, Body: out}, nil
+	}
+}
+
+// decodeBody decodes a JSON request body into target.
+// An absent or empty body leaves target untouched, and a body with anything but whitespace after its JSON value
+// is rejected.
+func decodeBody(request *Request, target any) error {
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil
+	}
+	decoder := json.NewDecoder(request.Body)
+	err := decoder.Decode(target)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("decoding body: %w", err)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("decoding body: unexpected data after JSON value")
+	}
+	return nil
+}
+
+// isStructPointer reports whether target is a non-nil pointer to a struct.
+func isStructPointer(target any) bool {
+	value := reflect.ValueOf(target)
+	return value.Kind() == reflect.Pointer && !value.IsNil() && value.Elem().Kind() == reflect.Struct
+}
@@ -0,0 +1,175 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type MockTypedInput struct {
+	ID    int    `path:"id" json:"-"`
+	Limit int    `query:"limit" json:"-"`
+	Name  string `json:"name"`
+}
+
+type MockTypedOutput struct {
+	Greeting string `json:"greeting"`
+}
+
+func MockTypedHandler(ctx context.Context, in MockTypedInput) (MockTypedOutput, error) {
+	return MockTypedOutput{Greeting: fmt.Sprintf("%s %d %d", in.Name, in.ID, in.Limit)}, nil
+}
+
+func serveTyped(handler Handler, method Method, target string, body string) *httptest.ResponseRecorder {
+	router := NewRouter()
+	router.Route(method, "/users/{id}/", handler)
+	writer := httptest.NewRecorder()
+	request := httptest.NewRequest(string(method), target, strings.NewReader(body))
+	router.ServeHTTP(writer, request)
+	return writer
+}
+
+func TestTypedDecodesBodyPathAndQuery(t *testing.T) {
+	writer := serveTyped(Typed(MockTypedHandler), GET, "/users/7/?limit=3", `{"name":"hello"}`)
+	if writer.Code != http.StatusOK {
+		t.Errorf(EXPECTED_DIGIT_ERROR, http.StatusOK, writer.Code)
+	}
+	expected := `{"greeting":"hello 7 3"}`
+	if writer.Body.String() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, writer.Body.String())
+	}
+}
+
+func TestTypedAcceptsEmptyBody(t *testing.T) {
+	writer := serveTyped(Typed(MockTypedHandler), GET, "/users/7/", "")
+	expected := `{"greeting":" 7 0"}`
+	if writer.Body.String() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, writer.Body.String())
+	}
+}
+
+func TestTypedReturnsCreatedForPost(t *testing.T) {
+	writer := serveTyped(Typed(MockTypedHandler), POST, "/users/7/", `{"name":"hello"}`)
+	if writer.Code != http.StatusCreated {
+		t.Errorf(EXPECTED_DIGIT_ERROR, http.StatusCreated, writer.Code)
+	}
+}
+
+func TestTypedReturnsBadRequestForMalformedBody(t *testing.T) {
+	writer := serveTyped(Typed(MockTypedHandler), POST, "/users/7/", `{"name":`)
+	if writer.Code != http.StatusBadRequest {
+		t.Errorf(EXPECTED_DIGIT_ERROR, http.StatusBadRequest, writer.Code)
+	}
+}
+
+func TestTypedReturnsBadRequestForTrailingData(t *testing.T) {
+	for _, body := range []string{`{"name":"a"}garbage`, `{}{}`} {
+		writer := serveTyped(Typed(MockTypedHandler), POST, "/users/7/", body)
+		if writer.Code != http.StatusBadRequest {
+			t.Errorf(EXPECTED_DIGIT_ERROR, http.StatusBadRequest, writer.Code)
+		}
+	}
+	writer := serveTyped(Typed(MockTypedHandler), POST, "/users/7/", "{\"name\":\"a\"}\n")
+	if writer.Code != http.StatusCreated {
+		t.Errorf(EXPECTED_DIGIT_ERROR, http.StatusCreated, writer.Code)
+	}
+}
+
+func TestTypedReturnsBadRequestForMalformedParameter(t *testing.T) {
+	writer := serveTyped(Typed(MockTypedHandler), GET, "/users/seven/", "")
+	if writer.Code != http.StatusBadRequest {
+		t.Errorf(EXPECTED_DIGIT_ERROR, http.StatusBadRequest, writer.Code)
+	}
+}
+
+func TestTypedSupportsPointerInput(t *testing.T) {
+	handler := Typed(func(ctx context.Context, in *MockTypedInput) (MockTypedOutput, error) {
+		return MockTypedOutput{Greeting: in.Name}, nil
+	})
+	writer := serveTyped(handler, POST, "/users/7/", `{"name":"hello"}`)
+	expected := `{"greeting":"hello"}`
+	if writer.Body.String() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, writer.Body.String())
+	}
+}
+
+func TestTypedReturnsNoContentForNilOutput(t *testing.T) {
+	handler := Typed(func(ctx context.Context, in MockTypedInput) (*MockTypedOutput, error) {
+		return nil, nil
+	})
+	writer := serveTyped(handler, GET, "/users/7/", "")
+	if writer.Code != http.StatusNoContent {
+		t.Errorf(EXPECTED_DIGIT_ERROR, http.StatusNoContent, writer.Code)
+	}
+}
+
+func TestTypedReturnsNoContentForNilResponseOutput(t *testing.T) {
+	handler := Typed(func(ctx context.Context, in MockTypedInput) (*MockResponse, error) {
+		return nil, nil
+	})
+	writer := serveTyped(handler, GET, "/users/7/", "")
+	if writer.Code != http.StatusNoContent {
+		t.Errorf(EXPECTED_DIGIT_ERROR, http.StatusNoContent, writer.Code)
+	}
+}
+
+func TestTypedWritesResponseOutputsAsIs(t *testing.T) {
+	handler := Typed(func(ctx context.Context, in MockTypedInput) (Response, error) {
+		return ServiceUnavailable{}, nil
+	})
+	writer := serveTyped(handler, GET, "/users/7/", "")
+	if writer.Code != http.StatusServiceUnavailable {
+		t.Errorf(EXPECTED_DIGIT_ERROR, http.StatusServiceUnavailable, writer.Code)
+	}
+}
+
+func TestTypedPropagatesErrors(t *testing.T) {
+	handler := Typed(func(ctx context.Context, in MockTypedInput) (MockTypedOutput, error) {
+		return MockTypedOutput{}, fmt.Errorf("error")
+	})
+	writer := serveTyped(handler, GET, "/users/7/", "")
+	if writer.Code != http.StatusInternalServerError {
+		t.Errorf(EXPECTED_DIGIT_ERROR, http.StatusInternalServerError, writer.Code)
+	}
+}
+
+func TestTypedPassesRequestContext(t *testing.T) {
+	var received context.Context
+	handler := Typed(func(ctx context.Context, in MockTypedInput) (MockTypedOutput, error) {
+		received = ctx
+		return MockTypedOutput{}, nil
+	})
+	serveTyped(handler, GET, "/users/7/", "")
+	if received == nil {
+		t.Error("Expected context to be passed")
+	}
+}
+
+func TestTypedReturnsUnprocessableEntityForInvalidInput(t *testing.T) {
+	handler := Typed(func(ctx context.Context, in struct {
+		Limit int `query:"limit" validate:"max=10"`
+	}) (MockTypedOutput, error) {
+		return MockTypedOutput{}, nil
+	})
+	writer := serveTyped(handler, GET, "/users/7/?limit=11", "")
+	if writer.Code != http.StatusUnprocessableEntity {
+		t.Errorf(EXPECTED_DIGIT_ERROR, http.StatusUnprocessableEntity, writer.Code)
+	}
+}
+
+func TestTypedPanicsOnMalformedRulesWhenCreated(t *testing.T) {
+	type input struct {
+		Address struct {
+			City string `validate:"requird"`
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error(PANIC_EXPECTED_ERROR)
+		}
+	}()
+	Typed(func(context.Context, *input) (any, error) { return nil, nil })
+}
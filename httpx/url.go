@@ -0,0 +1,78 @@
+package httpx
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// URL returns the path of the route with the given name, including the paths at which its router is linked.
+// Params are pairs of names and values. Values of the path parameters of the route are escaped into the path;
+// the remaining pairs are escaped into the query string, in the order given. URL panics if no route or more than one route has
+// the name, if params has an odd length, or if a path parameter is not given a non-empty value that satisfies its constraint.
+func (router *Router) URL(name string, params ...string) string {
+	if len(params)%2 != 0 {
+		panic(fmt.Sprintf("url: route %q needs parameters in name and value pairs, got %d values", name, len(params)))
+	}
+	var route *RouteInfo
+	for _, info := range router.Routes() {
+		if info.Name != name {
+			continue
+		}
+		if route != nil {
+			panic(fmt.Sprintf("url: route name %q is used by both %s %s and %s %s", name, route.Method, route.Path, info.Method, info.Path))
+		}
+		route = &info
+	}
+	if route == nil {
+		panic(fmt.Sprintf("url: no route is named %q", name))
+	}
+	used := map[string]bool{}
+	lookup := func(param string) (string, bool) {
+		for i := 0; i < len(params); i += 2 {
+			if params[i] == param {
+				used[param] = true
+				return params[i+1], true
+			}
+		}
+		return "", false
+	}
+	segments := strings.Split(route.Path, "/")
+	for i, segment := range segments {
+		if segment == "{$}" {
+			segments[i] = ""
+			continue
+		}
+		param, ok := parseParam(segment)
+		if !ok {
+			continue
+		}
+		value, ok := lookup(param.name)
+		if !ok || value == "" {
+			panic(fmt.Sprintf("url: route %q needs a value for path parameter %s", name, param.name))
+		}
+		if param.matches != nil && !param.matches(value) {
+			panic(fmt.Sprintf("url: route %q needs a value for path parameter %s that satisfies %s, got %q", name, param.name, param.constraint, value))
+		}
+		if param.remainder {
+			parts := strings.Split(value, "/")
+			for j, part := range parts {
+				parts[j] = url.PathEscape(part)
+			}
+			segments[i] = strings.Join(parts, "/")
+		} else {
+			segments[i] = url.PathEscape(value)
+		}
+	}
+	path := strings.Join(segments, "/")
+	var query []string
+	for i := 0; i < len(params); i += 2 {
+		if !used[params[i]] {
+			query = append(query, url.QueryEscape(params[i])+"="+url.QueryEscape(params[i+1]))
+		}
+	}
+	if len(query) == 0 {
+		return path
+	}
+	return path + "?" + strings.Join(query, "&")
+}
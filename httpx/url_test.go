@@ -0,0 +1,70 @@
+package httpx
+
+import (
+	"testing"
+)
+
+func expectURLPanic(t *testing.T, build func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Error(PANIC_EXPECTED_ERROR)
+		}
+	}()
+	build()
+}
+
+func TestURLBuildsPathOfNamedRoute(t *testing.T) {
+	router := NewRouter().Route(GET, "/users/{id}/posts/{post}/", listUsers, Name("post"))
+	if url := router.URL("post", "id", "42", "post", "7"); url != "/users/42/posts/7/" {
+		t.Errorf(EXPECTED_STRING_ERROR, "/users/42/posts/7/", url)
+	}
+}
+
+func TestURLIncludesLinkPrefixes(t *testing.T) {
+	users := NewRouter().Route(GET, "/{id}/", listUsers, Name("user"))
+	router := NewRouter().Link("/v1/", NewRouter().Link("/users/", users))
+	if url := router.URL("user", "id", "42"); url != "/v1/users/42/" {
+		t.Errorf(EXPECTED_STRING_ERROR, "/v1/users/42/", url)
+	}
+}
+
+func TestURLEscapesPathParamsAndQuery(t *testing.T) {
+	router := NewRouter().Route(GET, "/files/{name}/", listUsers, Name("file"))
+	url := router.URL("file", "name", "a b/c?d", "q", "x&y=z", "tag", "1", "tag", "2")
+	expected := "/files/a%20b%2Fc%3Fd/?q=x%26y%3Dz&tag=1&tag=2"
+	if url != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, url)
+	}
+}
+
+func TestURLHandlesWildcardsAndExactMatches(t *testing.T) {
+	router := NewRouter()
+	router.handle(RouteInfo{Method: GET, Path: "/{$}", Name: "home"}, adapt(listUsers))
+	router.handle(RouteInfo{Method: GET, Path: "/docs/{path...}", Name: "docs"}, adapt(listUsers))
+	if url := router.URL("home"); url != "/" {
+		t.Errorf(EXPECTED_STRING_ERROR, "/", url)
+	}
+	if url := router.URL("docs", "path", "guides/getting started.md"); url != "/docs/guides/getting%20started.md" {
+		t.Errorf(EXPECTED_STRING_ERROR, "/docs/guides/getting%20started.md", url)
+	}
+}
+
+func TestURLPanicsOnMisuse(t *testing.T) {
+	users := NewRouter().Route(GET, "/{id}/", listUsers, Name("user"))
+	router := NewRouter().Link("/a/", users).Link("/b/", users)
+	single := NewRouter().Route(GET, "/{id}/", listUsers, Name("user"))
+	tests := map[string]func(){
+		"unknown name":    func() { single.URL("missing") },
+		"missing param":   func() { single.URL("user") },
+		"empty param":     func() { single.URL("user", "id", "") },
+		"odd params":      func() { single.URL("user", "id") },
+		"ambiguous name":  func() { router.URL("user", "id", "1") },
+		"duplicate route": func() { single.Route(POST, "/{id}/", listUsers, Name("user")) },
+	}
+	for name, build := range tests {
+		t.Run(name, func(t *testing.T) {
+			expectURLPanic(t, build)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package httpx
+
+import (
+	"encoding/hex"
+	"errors"
+)
+
+// UUID is a 128 bit universally unique identifier as described in RFC 9562.
+type UUID [16]byte
+
+// ErrInvalidUUID is returned when a string is not a UUID in its canonical textual form.
+var ErrInvalidUUID = errors.New("invalid uuid")
+
+// ParseUUID parses a UUID in the canonical xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx form.
+// Hexadecimal digits may be upper or lower case.
+func ParseUUID(value string) (UUID, error) {
+	var uuid UUID
+	if len(value) != 36 || value[8] != '-' || value[13] != '-' || value[18] != '-' || value[23] != '-' {
+		return uuid, ErrInvalidUUID
+	}
+	digits := value[0:8] + value[9:13] + value[14:18] + value[19:23] + value[24:36]
+	if _, err := hex.Decode(uuid[:], []byte(digits)); err != nil {
+		return UUID{}, ErrInvalidUUID
+	}
+	return uuid, nil
+}
+
+// String returns the canonical, lower case textual form of the UUID.
+func (uuid UUID) String() string {
+	buffer := make([]byte, 36)
+	hex.Encode(buffer[0:8], uuid[0:4])
+	buffer[8] = '-'
+	hex.Encode(buffer[9:13], uuid[4:6])
+	buffer[13] = '-'
+	hex.Encode(buffer[14:18], uuid[6:8])
+	buffer[18] = '-'
+	hex.Encode(buffer[19:23], uuid[8:10])
+	buffer[23] = '-'
+	hex.Encode(buffer[24:36], uuid[10:16])
+	return string(buffer)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (uuid UUID) MarshalText() ([]byte, error) {
+	return []byte(uuid.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (uuid *UUID) UnmarshalText(text []byte) error {
+	parsed, err := ParseUUID(string(text))
+	if err != nil {
+		return err
+	}
+	*uuid = parsed
+	return nil
+}
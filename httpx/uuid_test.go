@@ -0,0 +1,52 @@
+package httpx
+
+import (
+	"testing"
+)
+
+const MOCK_UUID = "0191d3a4-5b6c-7d8e-9fa0-b1c2d3e4f5a6"
+
+func TestParseUUIDParsesCanonicalForm(t *testing.T) {
+	uuid, err := ParseUUID(MOCK_UUID)
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if uuid[0] != 0x01 || uuid[15] != 0xa6 {
+		t.Errorf("Unexpected bytes %v", uuid)
+	}
+}
+
+func TestParseUUIDAcceptsUpperCase(t *testing.T) {
+	uuid, err := ParseUUID("0191D3A4-5B6C-7D8E-9FA0-B1C2D3E4F5A6")
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if uuid.String() != MOCK_UUID {
+		t.Errorf(EXPECTED_STRING_ERROR, MOCK_UUID, uuid.String())
+	}
+}
+
+func TestParseUUIDRejectsWrongLength(t *testing.T) {
+	if _, err := ParseUUID(MOCK_UUID[1:]); err != ErrInvalidUUID {
+		t.Errorf("Expected ErrInvalidUUID, got %v", err)
+	}
+}
+
+func TestParseUUIDRejectsMisplacedHyphens(t *testing.T) {
+	if _, err := ParseUUID("0191d3a45-b6c-7d8e-9fa0-b1c2d3e4f5a6"); err != ErrInvalidUUID {
+		t.Errorf("Expected ErrInvalidUUID, got %v", err)
+	}
+}
+
+func TestParseUUIDRejectsNonHexDigits(t *testing.T) {
+	if _, err := ParseUUID("0191d3a4-5b6c-7d8e-9fa0-b1c2d3e4f5ag"); err != ErrInvalidUUID {
+		t.Errorf("Expected ErrInvalidUUID, got %v", err)
+	}
+}
+
+func TestUUIDStringRoundTrips(t *testing.T) {
+	uuid, _ := ParseUUID(MOCK_UUID)
+	if uuid.String() != MOCK_UUID {
+		t.Errorf(EXPECTED_STRING_ERROR, MOCK_UUID, uuid.String())
+	}
+}
@@ -0,0 +1,247 @@
+package httpx
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ValidationError is returned by Validate when one or more fields break their rules.
+// It is written as an UnprocessableEntity when returned from a Handler.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (err *ValidationError) Error() string {
+	messages := make([]string, len(err.Fields))
+	for i, field := range err.Fields {
+		messages[i] = field.Error()
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+func (err *ValidationError) Write(writer ResponseWriter) error {
+	return UnprocessableEntity{Fields: err.Fields}.Write(writer)
+}
+
+// Validate checks the fields of the struct pointed to by target against the comma separated rules in their
+// `validate:"..."` tags: required, min=n and max=n on numbers or lengths, email, and oneof=a b c.
+// Nil pointers are only checked by required, and nested and embedded structs are validated recursively.
+// Every field is checked; all failures are reported together in a *ValidationError.
+// Validate panics if a tag contains an unknown or malformed rule; Typed does so when it is called instead.
+func Validate(target any) error {
+	value := reflect.ValueOf(target)
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return fmt.Errorf("validate: target must be a struct or a pointer to a struct, got %T", target)
+	}
+	checkRules(value.Type())
+	var errors []FieldError
+	validateStruct(value, "", &errors)
+	if len(errors) > 0 {
+		return &ValidationError{Fields: errors}
+	}
+	return nil
+}
+
+func validateStruct(value reflect.Value, prefix string, errors *[]FieldError) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		fieldValue := value.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			validateStruct(fieldValue, prefix, errors)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		name, location := fieldName(field)
+		name = prefix + name
+		for _, rule := range splitRules(field.Tag.Get("validate")) {
+			if message := checkRule(fieldValue, rule); message != "" {
+				*errors = append(*errors, FieldError{
+					Field:    name,
+					Location: location,
+					Rule:     rule,
+					Message:  message,
+				})
+			}
+		}
+		for fieldValue.Kind() == reflect.Pointer && !fieldValue.IsNil() {
+			fieldValue = fieldValue.Elem()
+		}
+		if fieldValue.Kind() == reflect.Struct && fieldValue.Type() != timeType {
+			validateStruct(fieldValue, name+".", errors)
+		}
+	}
+}
+
+// fieldName returns the name under which a field is reported and the location it was bound from.
+// Bound fields use their binding tag, other fields use their JSON name or, failing that, their Go name.
+func fieldName(field reflect.StructField) (string, string) {
+	for _, location := range bindSources {
+		if name, ok := field.Tag.Lookup(location); ok {
+			return name, location
+		}
+	}
+	if tag, ok := field.Tag.Lookup("json"); ok {
+		if name, _, _ := strings.Cut(tag, ","); name != "" && name != "-" {
+			return name, ""
+		}
+	}
+	return field.Name, ""
+}
+
+func splitRules(tag string) []string {
+	if tag == "" {
+		return nil
+	}
+	return strings.Split(tag, ",")
+}
+
+// checkedRules holds the struct types whose rules checkRules has found to be valid.
+var checkedRules sync.Map
+
+// checkRules panics if a struct type, or a struct nested in it, has an unknown or malformed rule.
+func checkRules(structType reflect.Type) {
+	if _, ok := checkedRules.Load(structType); ok {
+		return
+	}
+	checkStructRules(structType, map[reflect.Type]bool{})
+	checkedRules.Store(structType, true)
+}
+
+func checkStructRules(structType reflect.Type, visited map[reflect.Type]bool) {
+	if visited[structType] {
+		return
+	}
+	visited[structType] = true
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			checkStructRules(field.Type, visited)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		for _, rule := range splitRules(field.Tag.Get("validate")) {
+			checkRuleType(fieldType, rule, field.Name)
+		}
+		if fieldType.Kind() == reflect.Struct && fieldType != timeType {
+			checkStructRules(fieldType, visited)
+		}
+	}
+}
+
+// checkRuleType panics if rule is unknown, malformed or does not apply to fields of fieldType.
+func checkRuleType(fieldType reflect.Type, rule string, fieldName string) {
+	name, argument, _ := strings.Cut(rule, "=")
+	switch name {
+	case "required":
+		return
+	case "min", "max":
+		if _, err := strconv.ParseFloat(argument, 64); err != nil {
+			panic(fmt.Sprintf("validate: rule %q on field %s needs a numeric argument", rule, fieldName))
+		}
+		switch fieldType.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64,
+			reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+			return
+		}
+		panic(fmt.Sprintf("validate: rule %q on field %s needs a number, string, slice or map", rule, fieldName))
+	case "email":
+		if fieldType.Kind() != reflect.String {
+			panic(fmt.Sprintf("validate: rule %q on field %s needs a string", rule, fieldName))
+		}
+		return
+	case "oneof":
+		if len(strings.Fields(argument)) == 0 {
+			panic(fmt.Sprintf("validate: rule %q on field %s needs at least one option", rule, fieldName))
+		}
+		return
+	}
+	panic(fmt.Sprintf("validate: unknown rule %q on field %s", rule, fieldName))
+}
+
+// checkRule returns a description of how value breaks rule, or "" if it does not.
+// The rule must have been checked by checkRules.
+func checkRule(value reflect.Value, rule string) string {
+	name, argument, _ := strings.Cut(rule, "=")
+	if name == "required" {
+		if value.IsZero() {
+			return "is required"
+		}
+		return ""
+	}
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+	switch name {
+	case "min", "max":
+		bound, _ := strconv.ParseFloat(argument, 64)
+		return checkBound(value, name, bound)
+	case "email":
+		address, err := mail.ParseAddress(value.String())
+		if value.Len() > 0 && (err != nil || address.Address != value.String()) {
+			return "must be a valid email address"
+		}
+		return ""
+	}
+	options := strings.Fields(argument)
+	actual := fmt.Sprint(value.Interface())
+	for _, option := range options {
+		if option == actual {
+			return ""
+		}
+	}
+	return fmt.Sprintf("must be one of %s", strings.Join(options, ", "))
+}
+
+// checkBound applies a min or max rule to a number, or to the length of a string, slice or map.
+func checkBound(value reflect.Value, rule string, bound float64) string {
+	var actual float64
+	atLeast, atMost, unit := "must be at least %s", "must be at most %s", ""
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		actual = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		actual = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		actual = value.Float()
+	case reflect.String:
+		actual = float64(utf8.RuneCountInString(value.String()))
+		atLeast, atMost, unit = "must be at least %s long", "must be at most %s long", "character"
+	default:
+		actual = float64(value.Len())
+		atLeast, atMost, unit = "must contain at least %s", "must contain at most %s", "item"
+	}
+	quantity := strconv.FormatFloat(bound, 'f', -1, 64)
+	if unit != "" && bound == 1 {
+		quantity += " " + unit
+	} else if unit != "" {
+		quantity += " " + unit + "s"
+	}
+	if rule == "min" && actual < bound {
+		return fmt.Sprintf(atLeast, quantity)
+	}
+	if rule == "max" && actual > bound {
+		return fmt.Sprintf(atMost, quantity)
+	}
+	return ""
+}
@@ -0,0 +1,187 @@
+package httpx
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type MockValidationAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type MockValidationTarget struct {
+	Limit    int                    `query:"limit" validate:"min=1,max=100"`
+	Name     string                 `json:"name" validate:"required,max=5"`
+	Email    string                 `json:"email" validate:"email"`
+	Kind     string                 `json:"kind" validate:"oneof=a b"`
+	Tags     []string               `json:"tags" validate:"min=1"`
+	Ratio    *float64               `json:"ratio,omitempty" validate:"max=1"`
+	Owner    *string                `json:"owner" validate:"required"`
+	Address  MockValidationAddress  `json:"address"`
+	Previous *MockValidationAddress `json:"previous"`
+	Plain    string                 `validate:"oneof=x"`
+}
+
+func CreateValidMockValidationTarget() MockValidationTarget {
+	owner := "me"
+	ratio := 0.5
+	return MockValidationTarget{
+		Limit:    10,
+		Name:     "name",
+		Email:    "someone@example.com",
+		Kind:     "a",
+		Tags:     []string{"x"},
+		Ratio:    &ratio,
+		Owner:    &owner,
+		Address:  MockValidationAddress{City: "Cape Town"},
+		Previous: &MockValidationAddress{City: "Durban"},
+		Plain:    "x",
+	}
+}
+
+func TestValidateAcceptsValidStruct(t *testing.T) {
+	target := CreateValidMockValidationTarget()
+	if err := Validate(&target); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := Validate(target); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestValidateReportsEveryFailingField(t *testing.T) {
+	ratio := 2.0
+	target := MockValidationTarget{
+		Limit:    0,
+		Name:     "too long",
+		Email:    "Someone <someone@example.com>",
+		Kind:     "c",
+		Ratio:    &ratio,
+		Previous: &MockValidationAddress{},
+	}
+	err := Validate(&target)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected *ValidationError, got %v", err)
+	}
+	expected := []FieldError{
+		{Field: "limit", Location: "query", Rule: "min=1", Message: "must be at least 1"},
+		{Field: "name", Rule: "max=5", Message: "must be at most 5 characters long"},
+		{Field: "email", Rule: "email", Message: "must be a valid email address"},
+		{Field: "kind", Rule: "oneof=a b", Message: "must be one of a, b"},
+		{Field: "tags", Rule: "min=1", Message: "must contain at least 1 item"},
+		{Field: "ratio", Rule: "max=1", Message: "must be at most 1"},
+		{Field: "owner", Rule: "required", Message: "is required"},
+		{Field: "address.city", Rule: "required", Message: "is required"},
+		{Field: "previous.city", Rule: "required", Message: "is required"},
+		{Field: "Plain", Rule: "oneof=x", Message: "must be one of x"},
+	}
+	if len(validationErr.Fields) != len(expected) {
+		t.Fatalf("Expected %d errors, got %v", len(expected), validationErr.Fields)
+	}
+	for i, field := range validationErr.Fields {
+		if field != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], field)
+		}
+	}
+}
+
+func TestValidateSkipsNilPointersForRulesOtherThanRequired(t *testing.T) {
+	target := CreateValidMockValidationTarget()
+	target.Ratio = nil
+	target.Previous = nil
+	if err := Validate(&target); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestValidateRejectsNonStructTargets(t *testing.T) {
+	if err := Validate([]string{}); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestValidatePanicsOnMalformedRules(t *testing.T) {
+	targets := []any{
+		struct {
+			Name string `validate:"shiny"`
+		}{},
+		struct {
+			Name string `validate:"min=one"`
+		}{},
+		struct {
+			Active bool `validate:"max=1"`
+		}{},
+		struct {
+			Count *int `validate:"email"`
+		}{},
+		struct {
+			Kind string `validate:"oneof="`
+		}{},
+		struct {
+			Previous *struct {
+				City string `validate:"requred"`
+			}
+		}{},
+	}
+	for _, target := range targets {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected panic for %T", target)
+				}
+			}()
+			Validate(target)
+		}()
+	}
+}
+
+type MockValidationNode struct {
+	MockValidationAddress
+	Name    string              `validate:"required"`
+	Next    *MockValidationNode `json:"next"`
+	visited bool
+}
+
+func TestValidateChecksRecursiveTypes(t *testing.T) {
+	address := MockValidationAddress{City: "Cape Town"}
+	err := Validate(MockValidationNode{MockValidationAddress: address, Name: "a", Next: &MockValidationNode{MockValidationAddress: address}})
+	expected := "validation failed: next.Name: is required"
+	if err == nil || err.Error() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, err)
+	}
+}
+
+func TestValidatePluralizesLengths(t *testing.T) {
+	target := struct {
+		Tags    []string `validate:"min=2"`
+		Code    string   `validate:"max=1"`
+		Retries uint     `validate:"max=3"`
+	}{Code: "ab", Retries: 4}
+	err := Validate(target)
+	expected := "validation failed: Tags: must contain at least 2 items; Code: must be at most 1 character long; Retries: must be at most 3"
+	if err == nil || err.Error() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, err)
+	}
+}
+
+func TestValidationErrorMessageListsFields(t *testing.T) {
+	err := &ValidationError{Fields: []FieldError{
+		{Field: "limit", Location: "query", Message: "must be at least 1"},
+		{Field: "name", Message: "is required"},
+	}}
+	expected := "validation failed: query limit: must be at least 1; name: is required"
+	if err.Error() != expected {
+		t.Errorf(EXPECTED_STRING_ERROR, expected, err.Error())
+	}
+}
+
+func TestValidationErrorIsWrittenAsUnprocessableEntity(t *testing.T) {
+	writer := httptest.NewRecorder()
+	(&ValidationError{Fields: []FieldError{{Field: "name", Rule: "required", Message: "is required"}}}).Write(writer)
+	if writer.Code != http.StatusUnprocessableEntity {
+		t.Errorf(EXPECTED_DIGIT_ERROR, http.StatusUnprocessableEntity, writer.Code)
+	}
+}
@@ -0,0 +1,119 @@
+package httpx
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIVersionHeader is the header in which clients may request a version of an API registered with Router.Version.
+const APIVersionHeader = "API-Version"
+
+// VersionOption configures a version of an API registered with Router.Version.
+type VersionOption func(*apiVersion)
+
+// apiVersion is a version of an API, served by its own router.
+type apiVersion struct {
+	name       string
+	router     *Router
+	deprecated time.Time
+	sunset     time.Time
+}
+
+// Deprecated marks a version as deprecated since the given time. Responses of the version carry a Deprecation
+// header with the time, as described in RFC 9745.
+func Deprecated(since time.Time) VersionOption {
+	return func(version *apiVersion) {
+		version.deprecated = since
+	}
+}
+
+// Sunset announces the time at which a version will stop being served. Responses of the version carry a Sunset
+// header with the time, as described in RFC 8594.
+func Sunset(at time.Time) VersionOption {
+	return func(version *apiVersion) {
+		version.sunset = at
+	}
+}
+
+// Version creates a router for the named version of an API, such as "v1", linked at the path of the version.
+// Requests that match no route of this router are also served by the version they request in the API-Version
+// header or the Accept header, as in "application/json; version=2", or else by the version set with DefaultVersion.
+// Requests for an unknown version are answered with 400 Bad Request.
+func (router *Router) Version(name string, options ...VersionOption) *Router {
+	version := &apiVersion{name: name, router: NewRouter()}
+	for _, option := range options {
+		option(version)
+	}
+	if !version.deprecated.IsZero() || !version.sunset.IsZero() {
+		version.router.Use(version.announce)
+	}
+	router.Link("/"+name+"/", version.router)
+	router.versions = append(router.versions, version)
+	return version.router
+}
+
+// DefaultVersion sets the version that serves requests which match no route of this router and request no version.
+// Requests for paths routed for other methods are still answered as MethodNotAllowed.
+func (router *Router) DefaultVersion(name string) *Router {
+	router.defaultVersion = name
+	return router
+}
+
+// announce is the middleware of deprecated versions, which sets the Deprecation and Sunset headers.
+func (version *apiVersion) announce(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if !version.deprecated.IsZero() {
+			writer.Header().Set("Deprecation", "@"+strconv.FormatInt(version.deprecated.Unix(), 10))
+		}
+		if !version.sunset.IsZero() {
+			writer.Header().Set("Sunset", version.sunset.UTC().Format(http.TimeFormat))
+		}
+		next.ServeHTTP(writer, request)
+	})
+}
+
+// serveVersion serves a request that matches no route with the version it requests, or with the default version,
+// and adds the headers it negotiates by to the Vary header. It reports false, without changing the response,
+// if the router has no versions, or if the request requests none and there is no default.
+func (router *Router) serveVersion(writer http.ResponseWriter, request *http.Request) bool {
+	if len(router.versions) == 0 {
+		return false
+	}
+	requested := request.Header.Get(APIVersionHeader)
+	if requested == "" {
+		requested = acceptedVersion(request)
+	}
+	if requested == "" {
+		if router.defaultVersion == "" {
+			return false
+		}
+		requested = router.defaultVersion
+	}
+	addVary(writer.Header(), APIVersionHeader, "Accept")
+	for _, version := range router.versions {
+		if strings.TrimPrefix(strings.ToLower(version.name), "v") == strings.TrimPrefix(strings.ToLower(requested), "v") {
+			version.router.ServeHTTP(writer, request)
+			return true
+		}
+	}
+	adapt(func(Request) (Response, error) {
+		return BadRequest{fmt.Errorf("unsupported API version %q", requested)}, nil
+	}).ServeHTTP(writer, request)
+	return true
+}
+
+// acceptedVersion returns the version parameter of the first media type in the Accept header of request that has one.
+func acceptedVersion(request *http.Request) string {
+	for _, value := range request.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(value, ",") {
+			if _, params, err := mime.ParseMediaType(mediaRange); err == nil && params["version"] != "" {
+				return params["version"]
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,126 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func CreateVersionedRouter() *Router {
+	router := NewRouter()
+	router.Version("v1", Deprecated(time.Unix(1700000000, 0)), Sunset(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))).
+		Route(GET, "/users/", respondWith("v1"))
+	router.Version("v2").Route(GET, "/users/", respondWith("v2"))
+	return router
+}
+
+func TestVersionsAreServedAtTheirPrefix(t *testing.T) {
+	router := CreateVersionedRouter()
+	for version, expected := range map[string]string{"/v1/users/": "v1", "/v2/users/": "v2"} {
+		if _, body := serveBody(router, "GET", version, nil); body != expected {
+			t.Errorf(EXPECTED_STRING_ERROR, expected, body)
+		}
+	}
+}
+
+func TestVersionsAreNegotiated(t *testing.T) {
+	router := CreateVersionedRouter()
+	if _, body := serveBody(router, "GET", "/users/", map[string]string{APIVersionHeader: "2"}); body != "v2" {
+		t.Errorf(EXPECTED_STRING_ERROR, "v2", body)
+	}
+	if _, body := serveBody(router, "GET", "/users/", map[string]string{"Accept": "text/html, application/json; version=v1"}); body != "v1" {
+		t.Errorf(EXPECTED_STRING_ERROR, "v1", body)
+	}
+	if code, _ := serveBody(router, "GET", "/users/", map[string]string{APIVersionHeader: "3"}); code != 400 {
+		t.Errorf(EXPECTED_DIGIT_ERROR, 400, code)
+	}
+}
+
+func TestDefaultVersionServesUnversionedRequests(t *testing.T) {
+	router := CreateVersionedRouter()
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/users/", nil))
+	if recorder.Code != 404 || recorder.Header().Get("Vary") != "" {
+		t.Errorf("Expected 404 without Vary, got %d %v", recorder.Code, recorder.Header().Values("Vary"))
+	}
+	router.DefaultVersion("v2")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/users/", nil))
+	if recorder.Body.String() != "v2" || recorder.Header().Get("Vary") != APIVersionHeader {
+		t.Errorf("Expected v2 to vary by %s, got %q %v", APIVersionHeader, recorder.Body.String(), recorder.Header().Values("Vary"))
+	}
+}
+
+func TestDefaultVersionLeavesRoutedPathsToMethodNotAllowed(t *testing.T) {
+	router := CreateVersionedRouter().DefaultVersion("v2").Route(GET, "/health/", respondWith("ok"))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("POST", "/health/", nil))
+	if recorder.Code != 405 || recorder.Header().Get("Allow") != "GET, HEAD, OPTIONS" || recorder.Header().Get("Vary") != "" {
+		t.Errorf("Expected 405 without Vary, got %d %q %v", recorder.Code, recorder.Header().Get("Allow"), recorder.Header().Values("Vary"))
+	}
+	if _, body := serveBody(router, "GET", "/users/", nil); body != "v2" {
+		t.Errorf(EXPECTED_STRING_ERROR, "v2", body)
+	}
+}
+
+func TestVersionedObjectResponsesAddToVary(t *testing.T) {
+	router := NewRouter().DefaultVersion("v1").Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			writer.Header().Set("Vary", "Origin")
+			next.ServeHTTP(writer, request)
+		})
+	})
+	router.Version("v1").Route(GET, "/users/", func(Request) (Response, error) {
+		return ObjectResponse{StatusCode: 200, Body: MockCodecItem{"a", 1}}, nil
+	})
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/users/", nil)
+	router.ServeHTTP(recorder, request.WithContext(serverWithAllEncoders().server.BaseContext(nil)))
+	expected := []string{"Origin", APIVersionHeader, "Accept"}
+	if !reflect.DeepEqual(recorder.Header().Values("Vary"), expected) {
+		t.Errorf("Expected Vary %v, got %v", expected, recorder.Header().Values("Vary"))
+	}
+}
+
+func TestVersionedResponsesKeepNegotiatedVaryValues(t *testing.T) {
+	router := NewRouter().DefaultVersion("v1")
+	router.Version("v1").Route(GET, "/users/", func(Request) (Response, error) {
+		return RawResponse{StatusCode: 200, Headers: http.Header{"Vary": {"Origin"}}}, nil
+	})
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/users/", nil))
+	expected := []string{APIVersionHeader, "Accept", "Origin"}
+	if !reflect.DeepEqual(recorder.Header().Values("Vary"), expected) {
+		t.Errorf("Expected Vary %v, got %v", expected, recorder.Header().Values("Vary"))
+	}
+}
+
+func TestDeprecatedVersionsAnnounceSunset(t *testing.T) {
+	router := CreateVersionedRouter()
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/v1/users/", nil))
+	if recorder.Header().Get("Deprecation") != "@1700000000" || recorder.Header().Get("Sunset") != "Tue, 01 Jan 2030 00:00:00 GMT" {
+		t.Errorf("Expected Deprecation and Sunset headers, got %v", recorder.Header())
+	}
+	recorder = httptest.NewRecorder()
+	negotiated := httptest.NewRequest("GET", "/users/", nil)
+	negotiated.Header.Set(APIVersionHeader, "v1")
+	router.ServeHTTP(recorder, negotiated)
+	if recorder.Header().Get("Deprecation") != "@1700000000" {
+		t.Errorf("Expected Deprecation header on negotiated version, got %v", recorder.Header())
+	}
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/v2/users/", nil))
+	if recorder.Header().Get("Deprecation") != "" || recorder.Header().Get("Sunset") != "" {
+		t.Errorf("Expected no Deprecation or Sunset headers for v2, got %v", recorder.Header())
+	}
+}
+
+func TestVersionRoutesAreListed(t *testing.T) {
+	routes := CreateVersionedRouter().Routes()
+	if len(routes) != 2 || routes[0].Path != "/v1/users/" || routes[1].Path != "/v2/users/" {
+		t.Errorf("Expected /v1/users/ and /v2/users/, got %+v", routes)
+	}
+}
@@ -0,0 +1,498 @@
+package httpx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// webSocketGUID is appended to the key of the client to compute the Sec-WebSocket-Accept header, as defined by RFC 6455.
+const webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// defaultWebSocketReadLimit is the size of the largest message a WebSocket reads unless SetReadLimit is called.
+const defaultWebSocketReadLimit = 64 * 1024
+
+// maxControlPayload is the size of the largest payload of a control frame.
+const maxControlPayload = 125
+
+// Frame opcodes, as defined by RFC 6455.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// ErrWebSocketClosed is returned when writing to a WebSocket after its close frame has been sent.
+var ErrWebSocketClosed = errors.New("websocket: connection closed")
+
+// MessageType is the type of a WebSocket data message.
+type MessageType int
+
+const (
+	// TextMessage carries UTF-8 encoded text.
+	TextMessage MessageType = opText
+	// BinaryMessage carries arbitrary bytes.
+	BinaryMessage MessageType = opBinary
+)
+
+// CloseCode is the status code of a WebSocket close frame, as defined by RFC 6455.
+type CloseCode int
+
+const (
+	CloseNormal          CloseCode = 1000
+	CloseGoingAway       CloseCode = 1001
+	CloseProtocolError   CloseCode = 1002
+	CloseUnsupportedData CloseCode = 1003
+	// CloseNoStatus is reported when a close frame has no status code. It is never sent.
+	CloseNoStatus           CloseCode = 1005
+	CloseInvalidPayload     CloseCode = 1007
+	ClosePolicyViolation    CloseCode = 1008
+	CloseMessageTooBig      CloseCode = 1009
+	CloseMandatoryExtension CloseCode = 1010
+	CloseInternalError      CloseCode = 1011
+)
+
+// CloseError is returned by WebSocket.ReadMessage once the connection has been closed by a close frame,
+// either one sent by the client or one sent because the client broke the protocol.
+// Returned from a WebSocketHandler, it closes the connection with its code and reason.
+type CloseError struct {
+	Code   CloseCode
+	Reason string
+}
+
+func (err *CloseError) Error() string {
+	if err.Reason == "" {
+		return fmt.Sprintf("websocket: closed with code %d", err.Code)
+	}
+	return fmt.Sprintf("websocket: closed with code %d: %s", err.Code, err.Reason)
+}
+
+// WebSocketHandler handles a WebSocket connection. The connection is closed once the handler returns:
+// normally if it returns nil, with the code of a returned *CloseError, and as an internal error otherwise.
+type WebSocketHandler = func(socket *WebSocket) error
+
+// WebSocket is a server side WebSocket connection, as defined by RFC 6455.
+// Messages must be read by a single goroutine, which also answers pings and close frames from the client.
+// Writes may be made concurrently with reads and with each other.
+type WebSocket struct {
+	conn      net.Conn
+	reader    *bufio.Reader
+	request   *http.Request
+	ctx       context.Context
+	cancel    context.CancelFunc
+	readLimit atomic.Int64
+	// idleTimeout, if positive, is the longest the connection may go without receiving a frame.
+	idleTimeout atomic.Int64
+	writeMutex  sync.Mutex
+	closeSent   bool
+}
+
+// WebSocket registers a handler for WebSocket connections on the given path, upgraded as described by RFC 6455.
+// Requests that are not WebSocket handshakes are answered with 426 Upgrade Required, and HEAD requests with 405.
+// Middleware that wraps the http.ResponseWriter must provide an Unwrap method, as upgrading takes over the
+// connection through http.ResponseController. Extensions and subprotocols are not negotiated.
+func (router *Router) WebSocket(path string, handler WebSocketHandler, options ...RouteOption) *Router {
+	validate(path)
+	upgrade := func(Request) (Response, error) {
+		return webSocketUpgrade{handler}, nil
+	}
+	router.handle(newRouteInfo(GET, path, handler, options), webSocketRoute{adapt(upgrade)})
+	return router
+}
+
+// webSocketRoute is the handler of a WebSocket route, which answers HEAD requests with 405 Method Not Allowed
+// instead of the response to a GET request.
+type webSocketRoute struct {
+	http.Handler
+}
+
+// Context returns the context of the connection, which is cancelled once the connection is closed.
+func (socket *WebSocket) Context() context.Context {
+	return socket.ctx
+}
+
+// Request returns the request that was upgraded to the connection.
+func (socket *WebSocket) Request() *Request {
+	return (*Request)(socket.request)
+}
+
+// SetReadLimit sets the size in bytes of the largest message that may be read, which is 64 KiB by default.
+// Larger messages close the connection with CloseMessageTooBig.
+func (socket *WebSocket) SetReadLimit(limit int64) {
+	socket.readLimit.Store(limit)
+}
+
+// KeepAlive pings the client every interval until the connection is closed.
+// The connection is closed if nothing, not even a pong, is received from the client for twice the interval,
+// which is only noticed while a goroutine is reading messages.
+func (socket *WebSocket) KeepAlive(interval time.Duration) {
+	socket.idleTimeout.Store(int64(2 * interval))
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-socket.ctx.Done():
+				return
+			case <-ticker.C:
+				if err := socket.Ping(nil); err != nil {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// ReadMessage reads the next data message, reassembling fragmented messages.
+// Pings received in the meantime are answered with pongs. Once the client closes the connection, or it is closed
+// because the client broke the protocol, sent invalid UTF-8 text or exceeded the read limit, a *CloseError is returned.
+func (socket *WebSocket) ReadMessage() (MessageType, []byte, error) {
+	var messageType MessageType
+	var message []byte
+	for {
+		header, err := socket.readHeader()
+		if err != nil {
+			return 0, nil, socket.abort(err)
+		}
+		if header.reserved != 0 {
+			return 0, nil, socket.fail(CloseProtocolError, "reserved bits set")
+		}
+		if !header.masked {
+			return 0, nil, socket.fail(CloseProtocolError, "client frames must be masked")
+		}
+		if header.opcode >= opClose {
+			if !header.fin || header.length > maxControlPayload {
+				return 0, nil, socket.fail(CloseProtocolError, "invalid control frame")
+			}
+			payload, err := socket.readPayload(header)
+			if err != nil {
+				return 0, nil, socket.abort(err)
+			}
+			if err := socket.control(header.opcode, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+		switch header.opcode {
+		case opContinuation:
+			if messageType == 0 {
+				return 0, nil, socket.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+		case opText, opBinary:
+			if messageType != 0 {
+				return 0, nil, socket.fail(CloseProtocolError, "expected continuation frame")
+			}
+			messageType = MessageType(header.opcode)
+		default:
+			return 0, nil, socket.fail(CloseProtocolError, "unknown opcode")
+		}
+		if int64(len(message))+int64(header.length) > socket.readLimit.Load() {
+			return 0, nil, socket.fail(CloseMessageTooBig, "message too big")
+		}
+		payload, err := socket.readPayload(header)
+		if err != nil {
+			return 0, nil, socket.abort(err)
+		}
+		message = append(message, payload...)
+		if header.fin {
+			if messageType == TextMessage && !utf8.Valid(message) {
+				return 0, nil, socket.fail(CloseInvalidPayload, "invalid UTF-8")
+			}
+			return messageType, message, nil
+		}
+	}
+}
+
+// WriteMessage writes a single data message. Text messages must be valid UTF-8.
+func (socket *WebSocket) WriteMessage(messageType MessageType, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return fmt.Errorf("websocket: invalid message type %d", messageType)
+	}
+	if messageType == TextMessage && !utf8.Valid(data) {
+		return errors.New("websocket: text message is not valid UTF-8")
+	}
+	return socket.writeFrame(byte(messageType), data)
+}
+
+// Ping sends a ping with the given payload, of at most 125 bytes. The client answers with a pong.
+func (socket *WebSocket) Ping(payload []byte) error {
+	if len(payload) > maxControlPayload {
+		return errors.New("websocket: ping payload too long")
+	}
+	return socket.writeFrame(opPing, payload)
+}
+
+// Close sends a close frame with the given code and reason, unless one has already been sent,
+// and closes the connection. Reasons are truncated to fit in a control frame.
+func (socket *WebSocket) Close(code CloseCode, reason string) error {
+	err := socket.writeClose(code, reason)
+	socket.cancel()
+	if closeErr := socket.conn.Close(); err == nil && !errors.Is(closeErr, net.ErrClosed) {
+		err = closeErr
+	}
+	if errors.Is(err, ErrWebSocketClosed) {
+		return nil
+	}
+	return err
+}
+
+// frameHeader is the header of a single frame.
+type frameHeader struct {
+	fin      bool
+	reserved byte
+	opcode   byte
+	masked   bool
+	length   uint64
+	mask     [4]byte
+}
+
+func (socket *WebSocket) readHeader() (frameHeader, error) {
+	if timeout := socket.idleTimeout.Load(); timeout > 0 {
+		socket.conn.SetReadDeadline(time.Now().Add(time.Duration(timeout)))
+	}
+	var header frameHeader
+	var start [2]byte
+	if _, err := io.ReadFull(socket.reader, start[:]); err != nil {
+		return header, err
+	}
+	header.fin = start[0]&0x80 != 0
+	header.reserved = start[0] & 0x70
+	header.opcode = start[0] & 0x0F
+	header.masked = start[1]&0x80 != 0
+	header.length = uint64(start[1] & 0x7F)
+	switch header.length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(socket.reader, extended[:]); err != nil {
+			return header, err
+		}
+		header.length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(socket.reader, extended[:]); err != nil {
+			return header, err
+		}
+		header.length = binary.BigEndian.Uint64(extended[:]) & (1<<63 - 1)
+	}
+	if header.masked {
+		if _, err := io.ReadFull(socket.reader, header.mask[:]); err != nil {
+			return header, err
+		}
+	}
+	return header, nil
+}
+
+func (socket *WebSocket) readPayload(header frameHeader) ([]byte, error) {
+	payload := make([]byte, header.length)
+	if _, err := io.ReadFull(socket.reader, payload); err != nil {
+		return nil, err
+	}
+	for i := range payload {
+		payload[i] ^= header.mask[i%4]
+	}
+	return payload, nil
+}
+
+// control handles a control frame received between the frames of data messages.
+func (socket *WebSocket) control(opcode byte, payload []byte) error {
+	switch opcode {
+	case opPing:
+		if err := socket.writeFrame(opPong, payload); err != nil && !errors.Is(err, ErrWebSocketClosed) {
+			return socket.abort(err)
+		}
+		return nil
+	case opPong:
+		return nil
+	case opClose:
+		code, reason := CloseNoStatus, ""
+		if len(payload) == 1 {
+			return socket.fail(CloseProtocolError, "invalid close frame")
+		}
+		if len(payload) >= 2 {
+			code, reason = CloseCode(binary.BigEndian.Uint16(payload)), string(payload[2:])
+			if !validCloseCode(code) {
+				return socket.fail(CloseProtocolError, "invalid close code")
+			}
+			if !utf8.ValidString(reason) {
+				return socket.fail(CloseInvalidPayload, "invalid UTF-8")
+			}
+		}
+		socket.Close(code, "")
+		return &CloseError{Code: code, Reason: reason}
+	}
+	return socket.fail(CloseProtocolError, "unknown opcode")
+}
+
+// validCloseCode reports whether code may be sent in a close frame.
+func validCloseCode(code CloseCode) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011, code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+// fail closes the connection with the given code and returns the corresponding *CloseError.
+func (socket *WebSocket) fail(code CloseCode, reason string) error {
+	socket.Close(code, reason)
+	return &CloseError{Code: code, Reason: reason}
+}
+
+// abort closes the connection after a read or write error, without a close frame, and returns err.
+func (socket *WebSocket) abort(err error) error {
+	socket.writeMutex.Lock()
+	socket.closeSent = true
+	socket.writeMutex.Unlock()
+	socket.Close(CloseNormal, "")
+	return err
+}
+
+func (socket *WebSocket) writeClose(code CloseCode, reason string) error {
+	var payload []byte
+	if code != CloseNoStatus {
+		if len(reason) > maxControlPayload-2 {
+			reason = reason[:maxControlPayload-2]
+		}
+		payload = binary.BigEndian.AppendUint16(nil, uint16(code))
+		payload = append(payload, reason...)
+	}
+	return socket.writeFrame(opClose, payload)
+}
+
+// writeFrame writes a single unmasked, final frame. No frames are written after a close frame.
+func (socket *WebSocket) writeFrame(opcode byte, payload []byte) error {
+	socket.writeMutex.Lock()
+	defer socket.writeMutex.Unlock()
+	if socket.closeSent {
+		return ErrWebSocketClosed
+	}
+	if opcode == opClose {
+		socket.closeSent = true
+	}
+	frame := make([]byte, 0, len(payload)+10)
+	frame = append(frame, 0x80|opcode)
+	switch length := len(payload); {
+	case length <= 125:
+		frame = append(frame, byte(length))
+	case length <= 0xFFFF:
+		frame = binary.BigEndian.AppendUint16(append(frame, 126), uint16(length))
+	default:
+		frame = binary.BigEndian.AppendUint64(append(frame, 127), uint64(length))
+	}
+	_, err := socket.conn.Write(append(frame, payload...))
+	return err
+}
+
+// webSocketUpgrade is the Response with which Router.WebSocket upgrades a request and runs its handler.
+type webSocketUpgrade struct {
+	handler WebSocketHandler
+}
+
+func (upgrade webSocketUpgrade) Write(writer ResponseWriter) error {
+	request := requestOf(writer)
+	httpWriter, ok := writer.(http.ResponseWriter)
+	if request == nil || !ok {
+		return InternalServerError{errors.New("websocket: upgrade requires the request")}.Write(writer)
+	}
+	if request.Method != http.MethodGet {
+		return MethodNotAllowed{Allowed: []Method{GET, OPTIONS}, Error: errors.New("websocket handshakes must use GET")}.Write(writer)
+	}
+	if !headerContainsToken(request.Header, "Connection", "upgrade") || !headerContainsToken(request.Header, "Upgrade", "websocket") {
+		return ErrorResponse{
+			StatusCode: http.StatusUpgradeRequired,
+			Message:    "upgrade required",
+			Headers:    http.Header{"Upgrade": {"websocket"}},
+		}.Write(writer)
+	}
+	if request.Header.Get("Sec-WebSocket-Version") != "13" {
+		return ErrorResponse{
+			StatusCode: http.StatusUpgradeRequired,
+			Message:    "upgrade required",
+			Error:      errors.New("unsupported websocket version"),
+			Headers:    http.Header{"Sec-WebSocket-Version": {"13"}},
+		}.Write(writer)
+	}
+	key := request.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return BadRequest{errors.New("invalid Sec-WebSocket-Key")}.Write(writer)
+	}
+	conn, buffered, err := http.NewResponseController(httpWriter).Hijack()
+	if err != nil {
+		return InternalServerError{fmt.Errorf("websocket: %w", err)}.Write(writer)
+	}
+	var handshake bytes.Buffer
+	handshake.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	header := writer.Header().Clone()
+	header.Set("Upgrade", "websocket")
+	header.Set("Connection", "Upgrade")
+	header.Set("Sec-WebSocket-Accept", acceptKey(key))
+	header.Write(&handshake)
+	handshake.WriteString("\r\n")
+	conn.SetDeadline(time.Time{})
+	if _, err := conn.Write(handshake.Bytes()); err != nil {
+		conn.Close()
+		return err
+	}
+	ctx, cancel := context.WithCancel(request.Context())
+	socket := &WebSocket{conn: conn, reader: buffered.Reader, request: request, ctx: ctx, cancel: cancel}
+	socket.readLimit.Store(defaultWebSocketReadLimit)
+	stop := context.AfterFunc(ctx, func() { socket.Close(CloseGoingAway, "") })
+	defer stop()
+	return upgrade.run(socket, writer)
+}
+
+// run calls the handler and closes the connection according to its outcome.
+// Panics are reported as they are for other handlers, and close the connection as an internal error.
+func (upgrade webSocketUpgrade) run(socket *WebSocket, writer ResponseWriter) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			socket.Close(CloseInternalError, "")
+			reportPanic(writer, socket.request, recovered)
+		}
+	}()
+	err = upgrade.handler(socket)
+	var closeErr *CloseError
+	switch {
+	case err == nil:
+		socket.Close(CloseNormal, "")
+	case errors.As(err, &closeErr):
+		socket.Close(closeErr.Code, closeErr.Reason)
+	default:
+		socket.Close(CloseInternalError, "")
+	}
+	return err
+}
+
+// acceptKey computes the Sec-WebSocket-Accept header for the given Sec-WebSocket-Key.
+func acceptKey(key string) string {
+	hash := sha1.Sum([]byte(key + webSocketGUID))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// headerContainsToken reports whether the comma separated values of the named header contain token, ignoring case.
+func headerContainsToken(header http.Header, name string, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, element := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(element), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,475 @@
+package httpx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const MOCK_WEBSOCKET_KEY = "dGhlIHNhbXBsZSBub25jZQ=="
+
+// MockWebSocketClient is a minimal RFC 6455 client, masking every frame it sends.
+type MockWebSocketClient struct {
+	conn     net.Conn
+	reader   *bufio.Reader
+	response *http.Response
+}
+
+func dialWebSocket(t *testing.T, server *httptest.Server, path string) *MockWebSocketClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Failed to dial, got %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	request, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+	request.Header.Set("Connection", "Upgrade")
+	request.Header.Set("Upgrade", "websocket")
+	request.Header.Set("Sec-WebSocket-Version", "13")
+	request.Header.Set("Sec-WebSocket-Key", MOCK_WEBSOCKET_KEY)
+	request.Write(conn)
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		t.Fatalf("Failed to read handshake, got %v", err)
+	}
+	return &MockWebSocketClient{conn, reader, response}
+}
+
+func (client *MockWebSocketClient) writeFrame(fin bool, opcode byte, payload []byte) {
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first}
+	switch {
+	case len(payload) <= 125:
+		frame = append(frame, 0x80|byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		frame = binary.BigEndian.AppendUint16(append(frame, 0x80|126), uint16(len(payload)))
+	default:
+		frame = binary.BigEndian.AppendUint64(append(frame, 0x80|127), uint64(len(payload)))
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	client.conn.Write(frame)
+}
+
+func (client *MockWebSocketClient) readFrame(t *testing.T) (byte, []byte) {
+	t.Helper()
+	var start [2]byte
+	if _, err := io.ReadFull(client.reader, start[:]); err != nil {
+		t.Fatalf("Failed to read frame, got %v", err)
+	}
+	length := int(start[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		io.ReadFull(client.reader, extended[:])
+		length = int(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		io.ReadFull(client.reader, extended[:])
+		length = int(binary.BigEndian.Uint64(extended[:]))
+	}
+	if start[1]&0x80 != 0 {
+		t.Error("Expected server frames to be unmasked")
+	}
+	payload := make([]byte, length)
+	io.ReadFull(client.reader, payload)
+	return start[0] & 0x0F, payload
+}
+
+func (client *MockWebSocketClient) expectClose(t *testing.T, code CloseCode) {
+	t.Helper()
+	opcode, payload := client.readFrame(t)
+	if opcode != opClose {
+		t.Fatalf(EXPECTED_DIGIT_ERROR, opClose, opcode)
+	}
+	if len(payload) < 2 || CloseCode(binary.BigEndian.Uint16(payload)) != code {
+		t.Errorf("Expected close code %d, got payload %v", code, payload)
+	}
+}
+
+func startWebSocketServer(handler WebSocketHandler) *httptest.Server {
+	router := NewRouter()
+	router.WebSocket("/ws/", handler)
+	return httptest.NewServer(router)
+}
+
+func echo(socket *WebSocket) error {
+	for {
+		messageType, message, err := socket.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if err := socket.WriteMessage(messageType, message); err != nil {
+			return err
+		}
+	}
+}
+
+func TestWebSocketCompletesHandshake(t *testing.T) {
+	server := startWebSocketServer(echo)
+	defer server.Close()
+	client := dialWebSocket(t, server, "/ws/")
+	if client.response.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf(EXPECTED_DIGIT_ERROR, http.StatusSwitchingProtocols, client.response.StatusCode)
+	}
+	if accept := client.response.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf(EXPECTED_STRING_ERROR, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", accept)
+	}
+}
+
+func TestWebSocketEchoesTextAndBinaryMessages(t *testing.T) {
+	server := startWebSocketServer(echo)
+	defer server.Close()
+	client := dialWebSocket(t, server, "/ws/")
+	client.writeFrame(true, opText, []byte("hello"))
+	if opcode, payload := client.readFrame(t); opcode != opText || string(payload) != "hello" {
+		t.Errorf("Expected text hello, got %d %q", opcode, payload)
+	}
+	large := bytes.Repeat([]byte{7}, 65000)
+	client.writeFrame(true, opBinary, large)
+	if opcode, payload := client.readFrame(t); opcode != opBinary || !bytes.Equal(payload, large) {
+		t.Errorf("Expected binary payload, got %d with %d bytes", opcode, len(payload))
+	}
+}
+
+func TestWebSocketReassemblesFragmentsAroundPings(t *testing.T) {
+	server := startWebSocketServer(echo)
+	defer server.Close()
+	client := dialWebSocket(t, server, "/ws/")
+	client.writeFrame(false, opText, []byte("hel"))
+	client.writeFrame(true, opPing, []byte("are you there"))
+	client.writeFrame(true, opContinuation, []byte("lo"))
+	if opcode, payload := client.readFrame(t); opcode != opPong || string(payload) != "are you there" {
+		t.Errorf("Expected pong, got %d %q", opcode, payload)
+	}
+	if opcode, payload := client.readFrame(t); opcode != opText || string(payload) != "hello" {
+		t.Errorf("Expected text hello, got %d %q", opcode, payload)
+	}
+}
+
+func TestWebSocketEchoesCloseFromClient(t *testing.T) {
+	result := make(chan error, 1)
+	server := startWebSocketServer(func(socket *WebSocket) error {
+		_, _, err := socket.ReadMessage()
+		result <- err
+		<-socket.Context().Done()
+		return err
+	})
+	defer server.Close()
+	client := dialWebSocket(t, server, "/ws/")
+	client.writeFrame(true, opClose, append(binary.BigEndian.AppendUint16(nil, uint16(CloseGoingAway)), "bye"...))
+	client.expectClose(t, CloseGoingAway)
+	var closeErr *CloseError
+	if err := <-result; !errors.As(err, &closeErr) || closeErr.Code != CloseGoingAway || closeErr.Reason != "bye" {
+		t.Errorf("Expected close error 1001 bye, got %v", err)
+	}
+}
+
+func TestWebSocketEchoesCloseWithoutStatus(t *testing.T) {
+	result := make(chan error, 1)
+	server := startWebSocketServer(func(socket *WebSocket) error {
+		_, _, err := socket.ReadMessage()
+		result <- err
+		return err
+	})
+	defer server.Close()
+	client := dialWebSocket(t, server, "/ws/")
+	client.writeFrame(true, opClose, nil)
+	if opcode, payload := client.readFrame(t); opcode != opClose || len(payload) != 0 {
+		t.Errorf("Expected empty close frame, got %d %v", opcode, payload)
+	}
+	var closeErr *CloseError
+	if err := <-result; !errors.As(err, &closeErr) || closeErr.Code != CloseNoStatus {
+		t.Errorf("Expected close error 1005, got %v", err)
+	}
+}
+
+func TestCloseErrorMessage(t *testing.T) {
+	tests := []struct {
+		err      *CloseError
+		expected string
+	}{
+		{&CloseError{Code: CloseNormal}, "websocket: closed with code 1000"},
+		{&CloseError{Code: ClosePolicyViolation, Reason: "denied"}, "websocket: closed with code 1008: denied"},
+	}
+	for _, test := range tests {
+		if test.err.Error() != test.expected {
+			t.Errorf(EXPECTED_STRING_ERROR, test.expected, test.err.Error())
+		}
+	}
+}
+
+func TestWebSocketClosesOnProtocolErrors(t *testing.T) {
+	tests := map[string]struct {
+		frames func(client *MockWebSocketClient)
+		code   CloseCode
+	}{
+		"unmasked":           {func(client *MockWebSocketClient) { client.conn.Write([]byte{0x81, 0x01, 'a'}) }, CloseProtocolError},
+		"continuation":       {func(client *MockWebSocketClient) { client.writeFrame(true, opContinuation, []byte("a")) }, CloseProtocolError},
+		"reserved bits":      {func(client *MockWebSocketClient) { client.writeFrame(true, 0x40|opText, []byte("a")) }, CloseProtocolError},
+		"unknown opcode":     {func(client *MockWebSocketClient) { client.writeFrame(true, 0x3, []byte("a")) }, CloseProtocolError},
+		"fragmented ping":    {func(client *MockWebSocketClient) { client.writeFrame(false, opPing, nil) }, CloseProtocolError},
+		"invalid utf-8":      {func(client *MockWebSocketClient) { client.writeFrame(true, opText, []byte{0xff}) }, CloseInvalidPayload},
+		"invalid close code": {func(client *MockWebSocketClient) { client.writeFrame(true, opClose, []byte{0x03, 0xed}) }, CloseProtocolError},
+		"short close frame":  {func(client *MockWebSocketClient) { client.writeFrame(true, opClose, []byte{0x03}) }, CloseProtocolError},
+		"invalid close text": {func(client *MockWebSocketClient) { client.writeFrame(true, opClose, []byte{0x03, 0xe8, 0xff}) }, CloseInvalidPayload},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := startWebSocketServer(echo)
+			defer server.Close()
+			client := dialWebSocket(t, server, "/ws/")
+			test.frames(client)
+			client.expectClose(t, test.code)
+		})
+	}
+}
+
+func TestWebSocketEnforcesReadLimit(t *testing.T) {
+	server := startWebSocketServer(func(socket *WebSocket) error {
+		socket.SetReadLimit(4)
+		return echo(socket)
+	})
+	defer server.Close()
+	client := dialWebSocket(t, server, "/ws/")
+	client.writeFrame(false, opBinary, []byte("abc"))
+	client.writeFrame(true, opContinuation, []byte("de"))
+	client.expectClose(t, CloseMessageTooBig)
+}
+
+func TestWebSocketClosesWithHandlerOutcome(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		code CloseCode
+	}{
+		"nil":         {nil, CloseNormal},
+		"close error": {&CloseError{Code: ClosePolicyViolation, Reason: "denied"}, ClosePolicyViolation},
+		"other error": {errors.New("failed"), CloseInternalError},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := startWebSocketServer(func(*WebSocket) error { return test.err })
+			defer server.Close()
+			client := dialWebSocket(t, server, "/ws/")
+			client.expectClose(t, test.code)
+		})
+	}
+}
+
+func TestWebSocketTruncatesLongCloseReasons(t *testing.T) {
+	server := startWebSocketServer(func(*WebSocket) error {
+		return &CloseError{Code: ClosePolicyViolation, Reason: strings.Repeat("a", 200)}
+	})
+	defer server.Close()
+	client := dialWebSocket(t, server, "/ws/")
+	if opcode, payload := client.readFrame(t); opcode != opClose || len(payload) != maxControlPayload {
+		t.Errorf("Expected close frame of %d bytes, got %d with %d bytes", maxControlPayload, opcode, len(payload))
+	}
+}
+
+func TestWebSocketReportsPanics(t *testing.T) {
+	reported := make(chan PanicReport, 1)
+	server := NewServer(ADDRESS).WithPanicReporter(func(_ context.Context, report PanicReport) { reported <- report })
+	router := NewRouter()
+	router.WebSocket("/ws/", func(*WebSocket) error { panic("boom") })
+	httpServer := httptest.NewUnstartedServer(router)
+	httpServer.Config.BaseContext = server.server.BaseContext
+	httpServer.Start()
+	defer httpServer.Close()
+	client := dialWebSocket(t, httpServer, "/ws/")
+	client.expectClose(t, CloseInternalError)
+	select {
+	case report := <-reported:
+		if report.Value != "boom" {
+			t.Errorf("Expected panic value boom, got %v", report.Value)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected panic to be reported")
+	}
+}
+
+func TestWebSocketKeepAlivePingsClient(t *testing.T) {
+	server := startWebSocketServer(func(socket *WebSocket) error {
+		socket.KeepAlive(10 * time.Millisecond)
+		return echo(socket)
+	})
+	defer server.Close()
+	client := dialWebSocket(t, server, "/ws/")
+	if opcode, _ := client.readFrame(t); opcode != opPing {
+		t.Errorf(EXPECTED_DIGIT_ERROR, opPing, opcode)
+	}
+	client.writeFrame(true, opPong, nil)
+	if opcode, _ := client.readFrame(t); opcode != opPing {
+		t.Errorf(EXPECTED_DIGIT_ERROR, opPing, opcode)
+	}
+}
+
+func TestWebSocketKeepAliveClosesUnresponsiveClients(t *testing.T) {
+	done := make(chan error, 1)
+	server := startWebSocketServer(func(socket *WebSocket) error {
+		socket.KeepAlive(10 * time.Millisecond)
+		_, _, err := socket.ReadMessage()
+		done <- err
+		return err
+	})
+	defer server.Close()
+	client := dialWebSocket(t, server, "/ws/")
+	defer client.conn.Close()
+	select {
+	case err := <-done:
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Errorf("Expected timeout, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected unresponsive client to be closed")
+	}
+}
+
+func TestWebSocketExposesRequestAndContext(t *testing.T) {
+	server := startWebSocketServer(func(socket *WebSocket) error {
+		if socket.Context().Err() != nil {
+			t.Error("Expected context to be live")
+		}
+		return socket.WriteMessage(TextMessage, []byte(socket.Request().URL.Query().Get("room")))
+	})
+	defer server.Close()
+	client := dialWebSocket(t, server, "/ws/?room=lobby")
+	if _, payload := client.readFrame(t); string(payload) != "lobby" {
+		t.Errorf(EXPECTED_STRING_ERROR, "lobby", payload)
+	}
+}
+
+func TestWebSocketWorksThroughMiddleware(t *testing.T) {
+	router := NewRouter()
+	router.WebSocket("/ws/", echo)
+	middleware := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			writer.Header().Set(RequestIDHeader, "abc")
+			next.ServeHTTP(&requestWriter{writer, request}, request)
+		})
+	}
+	server := httptest.NewServer(middleware(router))
+	defer server.Close()
+	client := dialWebSocket(t, server, "/ws/")
+	if client.response.Header.Get(RequestIDHeader) != "abc" {
+		t.Errorf(EXPECTED_STRING_ERROR, "abc", client.response.Header.Get(RequestIDHeader))
+	}
+	client.writeFrame(true, opText, []byte("hi"))
+	if _, payload := client.readFrame(t); string(payload) != "hi" {
+		t.Errorf(EXPECTED_STRING_ERROR, "hi", payload)
+	}
+}
+
+func TestWebSocketRejectsInvalidHandshakes(t *testing.T) {
+	server := startWebSocketServer(echo)
+	defer server.Close()
+	tests := map[string]struct {
+		headers map[string]string
+		status  int
+	}{
+		"plain request": {map[string]string{}, http.StatusUpgradeRequired},
+		"wrong version": {map[string]string{"Connection": "keep-alive, Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "8"}, http.StatusUpgradeRequired},
+		"invalid key":   {map[string]string{"Connection": "Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "13", "Sec-WebSocket-Key": "short"}, http.StatusBadRequest},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			request, _ := http.NewRequest(http.MethodGet, server.URL+"/ws/", nil)
+			for key, value := range test.headers {
+				request.Header.Set(key, value)
+			}
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatalf("Failed to make request, got %v", err)
+			}
+			response.Body.Close()
+			if response.StatusCode != test.status {
+				t.Errorf(EXPECTED_DIGIT_ERROR, test.status, response.StatusCode)
+			}
+		})
+	}
+}
+
+func TestWebSocketRefusesWritesAfterClose(t *testing.T) {
+	result := make(chan error, 1)
+	server := startWebSocketServer(func(socket *WebSocket) error {
+		socket.Close(CloseNormal, "")
+		result <- socket.WriteMessage(TextMessage, []byte("late"))
+		return nil
+	})
+	defer server.Close()
+	dialWebSocket(t, server, "/ws/")
+	if err := <-result; !errors.Is(err, ErrWebSocketClosed) {
+		t.Errorf("Expected ErrWebSocketClosed, got %v", err)
+	}
+}
+
+func TestWebSocketRejectsInvalidWrites(t *testing.T) {
+	result := make(chan []error, 1)
+	server := startWebSocketServer(func(socket *WebSocket) error {
+		errs := []error{
+			socket.WriteMessage(MessageType(opPing), []byte("a")),
+			socket.WriteMessage(TextMessage, []byte{0xff}),
+			socket.Ping(bytes.Repeat([]byte("a"), maxControlPayload+1)),
+		}
+		socket.conn.Close()
+		result <- append(errs, socket.WriteMessage(BinaryMessage, []byte("a")))
+		return nil
+	})
+	defer server.Close()
+	dialWebSocket(t, server, "/ws/")
+	for i, err := range <-result {
+		if err == nil || errors.Is(err, ErrWebSocketClosed) {
+			t.Errorf("Expected write %d to fail, got %v", i, err)
+		}
+	}
+}
+
+func TestWebSocketRejectsHeadHandshakes(t *testing.T) {
+	called := false
+	server := startWebSocketServer(func(*WebSocket) error {
+		called = true
+		return nil
+	})
+	defer server.Close()
+	request, _ := http.NewRequest(http.MethodHead, server.URL+"/ws/", nil)
+	request.Header.Set("Connection", "Upgrade")
+	request.Header.Set("Upgrade", "websocket")
+	request.Header.Set("Sec-WebSocket-Version", "13")
+	request.Header.Set("Sec-WebSocket-Key", MOCK_WEBSOCKET_KEY)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("Failed to make request, got %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusMethodNotAllowed || response.Header.Get("Allow") != "GET, OPTIONS" || called {
+		t.Errorf("Expected 405 with Allow GET, OPTIONS, got %d %q", response.StatusCode, response.Header.Get("Allow"))
+	}
+}
+
+func TestWebSocketRoutesDoNotAdvertiseHead(t *testing.T) {
+	router := NewRouter().WebSocket("/ws/", func(*WebSocket) error { return nil })
+	for _, method := range []string{http.MethodOptions, http.MethodPost, http.MethodHead} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(method, "/ws/", nil))
+		if recorder.Header().Get("Allow") != "GET, OPTIONS" {
+			t.Errorf("Expected %s to allow GET, OPTIONS, got %q", method, recorder.Header().Get("Allow"))
+		}
+	}
+}